@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/onozaty/emv/pkg/emv"
+	"github.com/pkg/errors"
+)
+
+type GetReport struct {
+	Files []emv.FileExtraction `json:"files"`
+}
+
+// runGet reports the values currently embedded in the target files.
+func runGet(configPath string, targetDirPath string, options Options, w io.Writer) error {
+
+	if err := validateOutput(options.Output); err != nil {
+		return err
+	}
+
+	config, err := emv.LoadConfig(configPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to load the config file")
+	}
+
+	fileExtractions, err := emv.NewEngine(config, targetDirPath).Extract()
+	if err != nil {
+		return err
+	}
+
+	if options.Output == "json" {
+		return writeJSON(w, GetReport{Files: fileExtractions})
+	}
+
+	for i, fileExtraction := range fileExtractions {
+
+		if i != 0 {
+			fmt.Fprintln(w)
+		}
+
+		fmt.Fprintf(w, "%s\n", fileExtraction.File)
+		for _, extraction := range fileExtraction.Rules {
+			fmt.Fprintf(w, "  Pattern: %s\n", extraction.Pattern)
+
+			if len(extraction.Matches) == 0 {
+				fmt.Fprintf(w, "    (No match)\n")
+			}
+
+			for _, match := range extraction.Matches {
+				prefix := fmt.Sprintf("    L%d: ", match.Line)
+				fmt.Fprintf(w, "%s%s\n", prefix, indentContinuation(match.Text, strings.Repeat(" ", len(prefix))))
+				for _, name := range sortedKeys(match.Values) {
+					value := strings.Join(append([]string{match.Values[name]}, match.Conflicts[name]...), ", ")
+					fmt.Fprintf(w, "      %s: %s\n", name, indentContinuation(value, "        "))
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateOutput(output string) error {
+
+	if output != "" && output != "text" && output != "json" {
+		return errors.Errorf("'%s' in --output is an invalid value, it must be text or json", output)
+	}
+
+	return nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	return errors.WithStack(encoder.Encode(v))
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunGet(t *testing.T) {
+
+	targetFile1 := createTempFile(t, "name=x\nversion=v1.0.0\nversion=v2.0.0")
+	defer os.Remove(targetFile1)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					},
+					{
+						"pattern" : "date=[0-9\\-]+",
+						"replacement" : "date={{.date}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := runGet(configFile, "", Options{}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	output := w.String()
+	expect := fmt.Sprintf(`%s
+  Pattern: version=v[0-9]+\.[0-9]+\.[0-9]+
+    L2: version=v1.0.0
+      version: 1.0.0
+    L3: version=v2.0.0
+      version: 2.0.0
+  Pattern: date=[0-9\-]+
+    (No match)
+`, targetFile1)
+
+	if output != expect {
+		t.Fatal("failed test\n", output)
+	}
+}
+
+func TestRunGet_json(t *testing.T) {
+
+	targetFile1 := createTempFile(t, "version=v1.0.0")
+	defer os.Remove(targetFile1)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := runGet(configFile, "", Options{Output: "json"}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	output := w.String()
+	expect := fmt.Sprintf(`{
+  "files": [
+    {
+      "file": "%s",
+      "rules": [
+        {
+          "pattern": "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+          "replacement": "version=v{{.version}}",
+          "matches": [
+            {
+              "line": 1,
+              "text": "version=v1.0.0",
+              "values": {
+                "version": "1.0.0"
+              }
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`, strings.ReplaceAll(targetFile1, `\`, `\\`))
+
+	if output != expect {
+		t.Fatal("failed test\n", output)
+	}
+}
+
+func TestRunGet_invalidOutput(t *testing.T) {
+
+	w := &bytes.Buffer{}
+	err := runGet("emv.json", "", Options{Output: "xml"}, w)
+	if err == nil || err.Error() != "'xml' in --output is an invalid value, it must be text or json" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
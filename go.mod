@@ -3,6 +3,10 @@ module github.com/onozaty/emv
 go 1.16
 
 require (
+	github.com/bmatcuk/doublestar/v4 v4.6.1
+	github.com/pelletier/go-toml/v2 v2.0.9
 	github.com/pkg/errors v0.9.1
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/spf13/pflag v1.0.5
+	gopkg.in/yaml.v3 v3.0.1
 )
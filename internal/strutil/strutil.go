@@ -0,0 +1,15 @@
+// Package strutil provides the string functions shared by the command and the engine.
+package strutil
+
+import "strings"
+
+// Cut is the same as strings.Cut, which is not available in Go 1.16.
+// It slices s around the first instance of sep.
+func Cut(s string, sep string) (string, string, bool) {
+
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
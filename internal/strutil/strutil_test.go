@@ -0,0 +1,24 @@
+package strutil
+
+import "testing"
+
+func TestCut(t *testing.T) {
+
+	tests := []struct {
+		s      string
+		before string
+		after  string
+		found  bool
+	}{
+		{"a=b=c", "a", "b=c", true},
+		{"a=", "a", "", true},
+		{"a", "a", "", false},
+	}
+
+	for _, test := range tests {
+		before, after, found := Cut(test.s, "=")
+		if before != test.before || after != test.after || found != test.found {
+			t.Fatal("failed test\n", test.s, before, after, found)
+		}
+	}
+}
@@ -1,15 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"html/template"
 	"io"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/onozaty/emv/internal/strutil"
+	"github.com/onozaty/emv/pkg/emv"
 	"github.com/pkg/errors"
 	flag "github.com/spf13/pflag"
 )
@@ -19,39 +21,36 @@ var (
 	Commit  = "none"
 )
 
-type Config struct {
-	Values  []Value  `json:"values"`
-	Targets []Target `json:"targets"`
-}
-
-type Value struct {
-	Name    string `json:"name"`
-	Pattern string `json:"pattern"`
-}
-
-type Target struct {
-	Files     []string   `json:"files"`
-	Embeddeds []Embedded `json:"embeddeds"`
-}
-
-type Embedded struct {
-	Pattern     string `json:"pattern"`
-	Replacement string `json:"replacement"`
-}
-
-type ReplaceRule struct {
-	Regex       *regexp.Regexp
-	Replacement string
+type Options struct {
+	Output         string
+	Bump           string
+	Sets           []string
+	ValuesFilePath string
+	DryRun         bool
+	Check          bool
+	Strict         bool
 }
 
 func main() {
 
 	var configPath string
 	var targetDirPath string
+	var sets []string
+	var valuesFilePath string
+	var dryRun bool
+	var check bool
+	var strict bool
+	var output string
 	var help bool
 
-	flag.StringVarP(&configPath, "config", "c", "emv.json", "Config file path.")
+	flag.StringVarP(&configPath, "config", "c", "", "Config file path. If not specified, emv.json, emv.yaml, emv.yml or emv.toml in the current directory is used.")
 	flag.StringVarP(&targetDirPath, "target", "t", "", "The base directory to search for target files. If not specified, it is the same directory as the config file.")
+	flag.StringArrayVarP(&sets, "set", "s", nil, "Value specified by name. (e.g. --set version=1.0.0)")
+	flag.StringVarP(&valuesFilePath, "values-file", "f", "", "JSON file with values specified by name. (e.g. {\"version\": \"1.0.0\"})")
+	flag.BoolVarP(&dryRun, "dry-run", "n", false, "Show the changes as a unified diff without writing files.")
+	flag.BoolVarP(&check, "check", "", false, "Check that the files are up to date without writing them. Exit with an error if any file would be changed.")
+	flag.BoolVarP(&strict, "strict", "", false, "Treat a target file that is not changed as an error, unless onUnchanged is specified in the target.")
+	flag.StringVarP(&output, "output", "o", "text", "Output format. (text or json)")
 	flag.BoolVarP(&help, "help", "h", false, "Help.")
 	flag.CommandLine.SortFlags = false
 	flag.Usage = func() {
@@ -65,196 +64,297 @@ func main() {
 		os.Exit(0)
 	}
 
-	if len(flag.Args()) == 0 {
-		usage(os.Stderr)
-		os.Exit(1)
+	if configPath == "" {
+		path, err := emv.FindConfigFile(".")
+		if err != nil {
+			printError(output, err)
+			os.Exit(1)
+		}
+		configPath = path
 	}
 
 	if targetDirPath == "" {
 		targetDirPath = filepath.Dir(configPath)
 	}
 
-	err := run(configPath, flag.Args(), targetDirPath, os.Stdout)
+	args := flag.Args()
+
+	var command string
+	if len(args) != 0 && (args[0] == "get" || args[0] == "verify-consistent" || args[0] == "validate") {
+		command = args[0]
+		args = args[1:]
+	}
+
+	var bumpPart string
+	if len(args) != 0 && args[0] == "bump" {
+		if len(args) < 2 {
+			usage(os.Stderr)
+			os.Exit(1)
+		}
+
+		bumpPart = args[1]
+		args = args[2:]
+	}
+
+	options := Options{
+		Output:         output,
+		Bump:           bumpPart,
+		Sets:           sets,
+		ValuesFilePath: valuesFilePath,
+		DryRun:         dryRun,
+		Check:          check,
+		Strict:         strict,
+	}
+
+	var err error
+	switch command {
+	case "get":
+		err = runGet(configPath, targetDirPath, options, os.Stdout)
+	case "verify-consistent":
+		err = runVerifyConsistent(configPath, targetDirPath, options, os.Stdout)
+	case "validate":
+		err = runValidate(configPath, options, os.Stdout)
+	default:
+		err = run(configPath, args, targetDirPath, options, os.Stdout)
+	}
+
 	if err != nil {
-		fmt.Println("\nError: ", err)
+		printError(output, err)
 		os.Exit(1)
 	}
 }
 
+// printError shows the error after the output.
+// With the JSON output, it is written to stderr so that stdout is kept as valid JSON.
+func printError(output string, err error) {
+
+	if output == "json" {
+		fmt.Fprintln(os.Stderr, "Error: ", err)
+		return
+	}
+
+	fmt.Println("\nError: ", err)
+}
+
 func usage(w io.Writer) {
 
 	fmt.Fprintf(w, "emv v%s (%s)\n\n", Version, Commit)
-	fmt.Fprintf(w, "Usage: emv [-c CONFIG] [-t TARGET] VALUE1 ... \n\nFlags\n")
+	fmt.Fprintf(w, "Usage: emv [-c CONFIG] [-t TARGET] [-s NAME=VALUE ...] [-f VALUES_FILE] [-n] [--check] [--strict] [-o text|json] [VALUE1 ...]\n")
+	fmt.Fprintf(w, "       emv bump major|minor|patch|prerelease [-c CONFIG] [-t TARGET] [-n] [--check] [--strict] [-o text|json] [VALUE2 ...]\n")
+	fmt.Fprintf(w, "       emv get [-c CONFIG] [-t TARGET] [-o text|json]\n")
+	fmt.Fprintf(w, "       emv verify-consistent [-c CONFIG] [-t TARGET] [-o text|json]\n")
+	fmt.Fprintf(w, "       emv validate [-c CONFIG] [-o text|json]\n\nFlags\n")
 	flag.CommandLine.SetOutput(w)
 	flag.PrintDefaults()
 }
 
-func run(configPath string, args []string, targetDirPath string, w io.Writer) error {
+func run(configPath string, args []string, targetDirPath string, options Options, w io.Writer) error {
+
+	if err := validateOutput(options.Output); err != nil {
+		return err
+	}
 
-	config, err := loadConfig(configPath)
+	config, err := emv.LoadConfig(configPath)
 	if err != nil {
 		return errors.Wrap(err, "failed to load the config file")
 	}
 
-	values, err := values(args, config.Values)
+	engine := emv.NewEngine(config, targetDirPath)
+
+	namedValues, err := namedValues(options.Sets, options.ValuesFilePath)
 	if err != nil {
 		return err
 	}
 
-	for i, target := range config.Targets {
-
-		if i != 0 {
-			fmt.Println()
-		}
-
-		replaceRules, err := buildReplaceRules(target.Embeddeds, values)
+	var bumped *emv.Bumped
+	if options.Bump != "" {
+		bumped, err = engine.Bump(options.Bump)
 		if err != nil {
 			return err
 		}
 
-		fmt.Fprintf(w, "Embedded values:\n")
-		for _, replaceRule := range replaceRules {
-			fmt.Fprintf(w, "  %s\n", replaceRule.Replacement)
+		if _, ok := namedValues[bumped.Name]; ok {
+			return errors.Errorf("'%s' cannot be specified because it is bumped", bumped.Name)
 		}
+		namedValues[bumped.Name] = bumped.Next
+	}
 
-		fmt.Fprintf(w, "Files: ([U] Updated, [-] None)\n")
-		for _, file := range target.Files {
-
-			targetFile := file
-			if !filepath.IsAbs(targetFile) && targetDirPath != "" {
-				targetFile = filepath.Join(targetDirPath, file)
-			}
+	values, err := engine.Values(args, namedValues)
+	if err != nil {
+		return err
+	}
 
-			replaced, err := replace(targetFile, replaceRules)
-			if err != nil {
-				return err
-			}
+	plan, err := engine.Plan(values)
+	if err != nil {
+		return err
+	}
 
-			var changeFlag string
-			if replaced {
-				changeFlag = "[U]"
-			} else {
-				changeFlag = "[-]"
-			}
+	warnings := plan.Warnings
 
-			fmt.Fprintf(w, "  %s %s\n", changeFlag, file)
+	// In check mode, unchanged files are the expected result.
+	if !options.Check {
+		unchangedWarnings, err := plan.CheckUnchanged(options.Strict)
+		if err != nil {
+			return err
 		}
+		warnings = append(warnings, unchangedWarnings...)
 	}
 
-	return nil
-}
-
-func replace(file string, replaceRules []ReplaceRule) (bool, error) {
-
-	content, err := os.ReadFile(file)
-	if err != nil {
-		return false, errors.WithStack(err)
+	if !options.DryRun && !options.Check {
+		if err := engine.Apply(plan); err != nil {
+			return err
+		}
 	}
 
-	before := string(content)
-	replaced := string(content)
+	outOfDateFiles := 0
+	if options.Check {
+		for _, targetPlan := range plan.Targets {
+			for _, file := range targetPlan.Files {
+				if file.Changed() {
+					outOfDateFiles++
+				}
+			}
+		}
+	}
 
-	for _, replaceRule := range replaceRules {
-		replaced = replaceRule.Regex.ReplaceAllString(replaced, replaceRule.Replacement)
+	if options.Output == "json" {
+		if err := writeJSON(w, runReport(options, bumped, values, plan.Targets, warnings)); err != nil {
+			return err
+		}
+	} else {
+		writeRunText(w, options, bumped, plan.Targets, warnings)
 	}
 
-	if before == replaced {
-		return false, nil
+	if outOfDateFiles != 0 {
+		return errors.Errorf("%d file(s) are out of date", outOfDateFiles)
 	}
 
-	return true, os.WriteFile(file, []byte(replaced), 0666)
+	return nil
 }
 
-func buildReplaceRules(embeddeds []Embedded, values map[string]string) ([]ReplaceRule, error) {
+func writeRunText(w io.Writer, options Options, bumped *emv.Bumped, targetPlans []emv.TargetPlan, warnings []string) {
 
-	replaceRules := []ReplaceRule{}
+	if bumped != nil {
+		fmt.Fprintf(w, "Bumped %s: %s -> %s\n\n", bumped.Name, bumped.Current, bumped.Next)
+	}
 
-	for _, emembedded := range embeddeds {
+	for i, targetPlan := range targetPlans {
 
-		regexp, err := regexp.Compile(emembedded.Pattern)
-		if err != nil {
-			return nil, errors.Wrapf(err, "'%s' in embeddeds-pattern is an invalid value", emembedded.Pattern)
+		if i != 0 {
+			fmt.Fprintln(w)
 		}
 
-		replacement, err := executeTemplate(emembedded.Replacement, values)
-		if err != nil {
-			return nil, errors.Wrapf(err, "'%s' in embeddeds-replacement is an invalid value", emembedded.Replacement)
+		fmt.Fprintf(w, "Embedded values:\n")
+		for _, replaceRule := range targetPlan.ReplaceRules {
+			fmt.Fprintf(w, "  %s\n", indentContinuation(replaceRule.Replacement, "  "))
 		}
 
-		replaceRules = append(replaceRules, ReplaceRule{
-			Regex:       regexp,
-			Replacement: replacement,
-		})
-	}
+		switch {
+		case options.Check:
+			fmt.Fprintf(w, "Files: ([U] Out of date, [-] Up to date)\n")
+		case options.DryRun:
+			fmt.Fprintf(w, "Files: ([U] Will be updated, [-] None)\n")
+		default:
+			fmt.Fprintf(w, "Files: ([U] Updated, [-] None)\n")
+		}
 
-	return replaceRules, nil
-}
+		for _, file := range targetPlan.Files {
 
-func executeTemplate(templStr string, values map[string]string) (string, error) {
+			var changeFlag string
+			if file.Changed() {
+				changeFlag = "[U]"
+			} else {
+				changeFlag = "[-]"
+			}
 
-	templ, err := template.New("template").Parse(templStr)
-	if err != nil {
-		return "", errors.WithStack(err)
-	}
+			fmt.Fprintf(w, "  %s %s\n", changeFlag, file.Name)
 
-	w := &strings.Builder{}
-	if err := templ.Execute(w, values); err != nil {
-		return "", errors.WithStack(err)
+			if file.Changed() && options.Check {
+				beforeLines := strings.SplitAfter(file.Before, "\n")
+				for _, line := range file.ChangedLines() {
+					fmt.Fprintf(w, "    L%d: %s\n", line, strings.TrimRight(beforeLines[line-1], "\r\n"))
+				}
+			} else if options.DryRun {
+				for _, line := range strings.SplitAfter(file.Diff(), "\n") {
+					if line != "" {
+						fmt.Fprintf(w, "    %s", line)
+					}
+				}
+			}
+		}
 	}
 
-	return w.String(), nil
+	if len(warnings) != 0 {
+		fmt.Fprintln(w)
+		for _, warning := range warnings {
+			fmt.Fprintf(w, "Warning: %s\n", warning)
+		}
+	}
 }
 
-func values(args []string, valueConfigs []Value) (map[string]string, error) {
-
-	values := map[string]string{}
-
-	if len(args) != len(valueConfigs) {
-		return nil, errors.Errorf("argument must be %d arguments", len(valueConfigs))
-	}
+func namedValues(sets []string, valuesFilePath string) (map[string]string, error) {
 
-	for i, valueConfig := range valueConfigs {
-		values[valueConfig.Name] = args[i]
+	namedValues := map[string]string{}
 
-		if valueConfig.Pattern != "" {
+	if valuesFilePath != "" {
+		content, err := os.ReadFile(valuesFilePath)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
 
-			regexp, err := regexp.Compile(valueConfig.Pattern)
-			if err != nil {
-				return nil, errors.Wrapf(err, "'%s' in values-pattern is an invalid value", valueConfig.Pattern)
-			}
+		var fileValues map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&fileValues); err != nil {
+			return nil, errors.Wrap(err, "failed to load the values file")
+		}
 
-			match := regexp.FindStringSubmatch(args[i])
-			if match == nil {
-				return nil, errors.Errorf("'%s' does not match the pattern: %s", args[i], valueConfig.Pattern)
+		for name, value := range fileValues {
+			switch value := value.(type) {
+			case string:
+				namedValues[name] = value
+			case json.Number, bool:
+				namedValues[name] = fmt.Sprint(value)
+			default:
+				return nil, errors.Errorf("'%s' in the values file must be a string, number or boolean", name)
 			}
+		}
+	}
 
-			for i, name := range regexp.SubexpNames() {
-				if i != 0 && name != "" {
-					values[name] = match[i]
-				}
-			}
+	for _, set := range sets {
+		name, value, found := strutil.Cut(set, "=")
+		if !found || name == "" {
+			return nil, errors.Errorf("'%s' in --set must be in the form name=value", set)
 		}
+
+		namedValues[name] = value
 	}
 
-	return values, nil
+	return namedValues, nil
 }
 
-func loadConfig(path string) (*Config, error) {
+func sortedKeys(m map[string]string) []string {
 
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, errors.WithStack(err)
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	var config Config
-	err = json.Unmarshal(content, &config)
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
+	return keys
+}
+
+// indentContinuation indents the lines after the first one, so that a multi-line text is aligned with its first line.
+func indentContinuation(text string, indent string) string {
 
-	if len(config.Targets) == 0 || len(config.Values) == 0 {
-		return nil, errors.Errorf("invalid format")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if i != 0 && line != "" {
+			line = indent + line
+		}
+		lines[i] = line
 	}
 
-	return &config, nil
+	return strings.Join(lines, "\n")
 }
@@ -6,7 +6,6 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
-	"regexp"
 	"strings"
 	"testing"
 
@@ -89,7 +88,7 @@ func TestRun(t *testing.T) {
 	defer os.Remove(configFile)
 
 	w := &bytes.Buffer{}
-	err := run(configFile, args, configFile, w)
+	err := run(configFile, args, configFile, Options{}, w)
 	if err != nil {
 		t.Fatalf("failed test\n%+v", err)
 	}
@@ -189,7 +188,7 @@ func TestRun_unchanged(t *testing.T) {
 	defer os.Remove(configFile)
 
 	w := &bytes.Buffer{}
-	err := run(configFile, args, "", w)
+	err := run(configFile, args, "", Options{}, w)
 	if err != nil {
 		t.Fatalf("failed test\n%+v", err)
 	}
@@ -263,7 +262,7 @@ func TestRun_targetDir(t *testing.T) {
 	defer os.Remove(configFile)
 
 	w := &bytes.Buffer{}
-	err := run(configFile, args, filepath.Dir(targetFile1), w)
+	err := run(configFile, args, filepath.Dir(targetFile1), Options{}, w)
 	if err != nil {
 		t.Fatalf("failed test\n%+v", err)
 	}
@@ -295,6 +294,1138 @@ func TestRun_targetDir(t *testing.T) {
 		t.Fatal("failed test\n", output)
 	}
 }
+func TestRun_dryRun(t *testing.T) {
+
+	args := []string{
+		"3.4.1",
+	}
+
+	targetFile1 := createTempFile(t, "name=x\nversion=v1.0.0\n")
+	defer os.Remove(targetFile1)
+	targetFile2 := createTempFile(t, `date=2021-11-24`)
+	defer os.Remove(targetFile2)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s",
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`),
+		strings.ReplaceAll(targetFile2, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, args, "", Options{DryRun: true}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// not changed
+	{
+		before := readString(t, targetFile1)
+		if before != "name=x\nversion=v1.0.0\n" {
+			t.Fatal("failed test\n", before)
+		}
+	}
+	{
+		before := readString(t, targetFile2)
+		if before != `date=2021-11-24` {
+			t.Fatal("failed test\n", before)
+		}
+	}
+
+	output := w.String()
+	expect := fmt.Sprintf(`Embedded values:
+  version=v3.4.1
+Files: ([U] Will be updated, [-] None)
+  [U] %s
+    --- %s
+    +++ %s
+    @@ -1,2 +1,2 @@
+     name=x
+    -version=v1.0.0
+    +version=v3.4.1
+  [-] %s
+`, targetFile1, targetFile1, targetFile1, targetFile2)
+
+	if output != expect {
+		t.Fatal("failed test\n", output)
+	}
+}
+
+func TestRun_check(t *testing.T) {
+
+	args := []string{
+		"3.4.1",
+	}
+
+	targetFile1 := createTempFile(t, "name=x\nversion=v1.0.0\nversion=v3.4.1\nversion=v2.0.0")
+	defer os.Remove(targetFile1)
+	targetFile2 := createTempFile(t, `version=v3.4.1`)
+	defer os.Remove(targetFile2)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s",
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`),
+		strings.ReplaceAll(targetFile2, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, args, "", Options{Check: true}, w)
+	if err == nil || err.Error() != "1 file(s) are out of date" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// not changed
+	{
+		before := readString(t, targetFile1)
+		if before != "name=x\nversion=v1.0.0\nversion=v3.4.1\nversion=v2.0.0" {
+			t.Fatal("failed test\n", before)
+		}
+	}
+
+	output := w.String()
+	expect := fmt.Sprintf(`Embedded values:
+  version=v3.4.1
+Files: ([U] Out of date, [-] Up to date)
+  [U] %s
+    L2: version=v1.0.0
+    L4: version=v2.0.0
+  [-] %s
+`, targetFile1, targetFile2)
+
+	if output != expect {
+		t.Fatal("failed test\n", output)
+	}
+}
+
+func TestRun_check_upToDate(t *testing.T) {
+
+	args := []string{
+		"3.4.1",
+	}
+
+	targetFile1 := createTempFile(t, `version=v3.4.1`)
+	defer os.Remove(targetFile1)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, args, "", Options{Check: true}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	output := w.String()
+	if !strings.Contains(output, fmt.Sprintf(`[-] %s`, targetFile1)) {
+		t.Fatal("failed test\n", output)
+	}
+}
+
+func TestRun_bump(t *testing.T) {
+
+	targetFile1 := createTempFile(t, "version=1.2.3\nbuild=1")
+	defer os.Remove(targetFile1)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version",
+				"pattern" : "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)\\.(?P<revision>[0-9]+)$",
+				"source" : {
+					"file" : "%s",
+					"pattern" : "version=([0-9\\.]+)"
+				}
+			},
+			{ 
+				"name" : "build"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=[0-9\\.]+",
+						"replacement" : "version={{.version}}"
+					},
+					{
+						"pattern" : "build=[0-9]+",
+						"replacement" : "build={{.build}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`),
+		strings.ReplaceAll(targetFile1, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, []string{"2"}, "", Options{Bump: "minor"}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	{
+		before := readString(t, targetFile1)
+		if before != "version=1.3.0\nbuild=2" {
+			t.Fatal("failed test\n", before)
+		}
+	}
+
+	output := w.String()
+	if !strings.HasPrefix(output, "Bumped version: 1.2.3 -> 1.3.0\n") {
+		t.Fatal("failed test\n", output)
+	}
+}
+
+func TestRun_bump_unmatchValuePattern(t *testing.T) {
+
+	targetFile1 := createTempFile(t, "version=1.2.3")
+	defer os.Remove(targetFile1)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version",
+				"pattern" : "^[0-9]+\\.[0-9]+\\.[0-9]+$",
+				"source" : {
+					"file" : "%s",
+					"pattern" : "version=([0-9\\.]+)"
+				}
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=[0-9\\.]+",
+						"replacement" : "version={{.version}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`),
+		strings.ReplaceAll(targetFile1, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, []string{}, "", Options{Bump: "prerelease"}, w)
+	if err == nil || err.Error() != "'1.2.4-0' does not match the pattern: ^[0-9]+\\.[0-9]+\\.[0-9]+$" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	{
+		before := readString(t, targetFile1)
+		if before != "version=1.2.3" {
+			t.Fatal("failed test\n", before)
+		}
+	}
+}
+
+func TestRun_notWrittenOnError(t *testing.T) {
+
+	args := []string{
+		"3.4.1",
+	}
+
+	targetFile1 := createTempFile(t, `version=v1.0.0`)
+	defer os.Remove(targetFile1)
+	targetFile2 := createTempFile(t, `version=v1.0.0`)
+	defer os.Remove(targetFile2)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					}
+				]
+			},
+			{
+				"files" : [
+					"%s",
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`),
+		strings.ReplaceAll(targetFile2, `\`, `\\`),
+		// file not found
+		strings.ReplaceAll(targetFile2+"xxxx", `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, args, "", Options{}, w)
+	pathErr := errors.Cause(err).(*os.PathError)
+	if pathErr.Path != targetFile2+"xxxx" || pathErr.Op != "open" {
+		t.Fatal("failed test\n", err)
+	}
+
+	// not changed
+	{
+		before := readString(t, targetFile1)
+		if before != `version=v1.0.0` {
+			t.Fatal("failed test\n", before)
+		}
+	}
+	{
+		before := readString(t, targetFile2)
+		if before != `version=v1.0.0` {
+			t.Fatal("failed test\n", before)
+		}
+	}
+}
+
+func TestRun_expect(t *testing.T) {
+
+	args := []string{
+		"3.4.1",
+	}
+
+	targetFile1 := createTempFile(t, "version=v1.0.0\nversion=v1.0.0")
+	defer os.Remove(targetFile1)
+	targetFile2 := createTempFile(t, `ver=v1.0.0`)
+	defer os.Remove(targetFile2)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s",
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}",
+						"expect" : {
+							"min" : 1,
+							"max" : 2
+						}
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`),
+		strings.ReplaceAll(targetFile2, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, args, "", Options{}, w)
+	if err == nil || err.Error() != fmt.Sprintf("'version=v[0-9]+\\.[0-9]+\\.[0-9]+' in embeddeds-pattern for %s: matched 0 times, but expected 1 to 2", targetFile2) {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// not changed
+	{
+		before := readString(t, targetFile1)
+		if before != "version=v1.0.0\nversion=v1.0.0" {
+			t.Fatal("failed test\n", before)
+		}
+	}
+}
+
+func TestRun_strict(t *testing.T) {
+
+	args := []string{
+		"3.4.1",
+	}
+
+	targetFile1 := createTempFile(t, `version=v1.0.0`)
+	defer os.Remove(targetFile1)
+	targetFile2 := createTempFile(t, `ver=v1.0.0`)
+	defer os.Remove(targetFile2)
+	targetFile3 := createTempFile(t, `ver=v1.0.0`)
+	defer os.Remove(targetFile3)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s",
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					}
+				]
+			},
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					}
+				],
+				"onUnchanged" : "warn"
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`),
+		strings.ReplaceAll(targetFile2, `\`, `\\`),
+		strings.ReplaceAll(targetFile3, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	{
+		w := &bytes.Buffer{}
+		err := run(configFile, args, "", Options{Strict: true}, w)
+		if err == nil || err.Error() != fmt.Sprintf("1 file(s) were not changed: %s", targetFile2) {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		// not changed
+		before := readString(t, targetFile1)
+		if before != `version=v1.0.0` {
+			t.Fatal("failed test\n", before)
+		}
+	}
+	{
+		w := &bytes.Buffer{}
+		err := run(configFile, args, "", Options{}, w)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		before := readString(t, targetFile1)
+		if before != `version=v3.4.1` {
+			t.Fatal("failed test\n", before)
+		}
+
+		output := w.String()
+		if !strings.HasSuffix(output, fmt.Sprintf("\nWarning: %s was not changed\n", targetFile3)) {
+			t.Fatal("failed test\n", output)
+		}
+	}
+}
+
+func TestRun_block(t *testing.T) {
+
+	targetFile := createTempFile(t, "# Install\n\n<!-- emv:begin install -->\n```\nnpm install x@1.0.0\nnpx x@1.0.0 init\n```\n<!-- emv:end -->\n")
+	defer os.Remove(targetFile)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"kind" : "block",
+						"begin" : "<!-- emv:begin install -->",
+						"end" : "<!-- emv:end -->",
+						"replacement" : "`+"```"+`\nnpm install x@{{.version}}\nnpx x@{{.version}} init\n`+"```"+`"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, []string{"2.0.0"}, "", Options{}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	after := readString(t, targetFile)
+	if after != "# Install\n\n<!-- emv:begin install -->\n```\nnpm install x@2.0.0\nnpx x@2.0.0 init\n```\n<!-- emv:end -->\n" {
+		t.Fatal("failed test\n", after)
+	}
+
+	// The lines of the block are indented.
+	if !strings.HasPrefix(w.String(), "Embedded values:\n  ```\n  npm install x@2.0.0\n  npx x@2.0.0 init\n  ```\nFiles:") {
+		t.Fatal("failed test\n", w.String())
+	}
+
+	// The embedded values can be read.
+	w = &bytes.Buffer{}
+	err = runGet(configFile, "", Options{}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	output := w.String()
+	expect := fmt.Sprintf(`%s
+  Pattern: <!-- emv:begin install -->
+    L4: `+"```"+`
+        npm install x@2.0.0
+        npx x@2.0.0 init
+        `+"```"+`
+      version: 2.0.0
+`, targetFile)
+
+	if output != expect {
+		t.Fatal("failed test\n", output)
+	}
+}
+
+func TestRun_jsonKind(t *testing.T) {
+
+	targetFile := createTempFile(t, "{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\",\n  \"dependencies\": {\n    \"lib\": { \"version\": \"1.0.0\" }\n  }\n}\n")
+	defer os.Remove(targetFile)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"kind" : "json",
+						"path" : "/version",
+						"replacement" : "{{.version}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, []string{"2.0.0"}, "", Options{}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// The version of the dependency is not changed.
+	after := readString(t, targetFile)
+	if after != "{\n  \"name\": \"app\",\n  \"version\": \"2.0.0\",\n  \"dependencies\": {\n    \"lib\": { \"version\": \"1.0.0\" }\n  }\n}\n" {
+		t.Fatal("failed test\n", after)
+	}
+
+	// The embedded values can be read.
+	w = &bytes.Buffer{}
+	err = runGet(configFile, "", Options{}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	output := w.String()
+	expect := fmt.Sprintf(`%s
+  Pattern: /version
+    L3: 2.0.0
+      version: 2.0.0
+`, targetFile)
+
+	if output != expect {
+		t.Fatal("failed test\n", output)
+	}
+}
+
+func TestRun_yamlKind(t *testing.T) {
+
+	targetFile := createTempFile(t, "image:\n  # The tag is updated by emv.\n  repository: app\n  tag: \"1.0.0\" # quoted\nsidecar:\n  tag: 1.0.0\n---\nimage:\n  tag: 1.0.0\n")
+	defer os.Remove(targetFile)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"kind" : "yaml",
+						"path" : "image.tag",
+						"replacement" : "{{.version}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, []string{"2.0.0"}, "", Options{}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// The comments and the quoting style are kept, and the tag of the sidecar is not changed.
+	after := readString(t, targetFile)
+	if after != "image:\n  # The tag is updated by emv.\n  repository: app\n  tag: \"2.0.0\" # quoted\nsidecar:\n  tag: 1.0.0\n---\nimage:\n  tag: 2.0.0\n" {
+		t.Fatal("failed test\n", after)
+	}
+}
+
+func TestRun_xmlKind(t *testing.T) {
+
+	targetFile := createTempFile(t, "<project>\n  <version>1.0.0</version>\n  <dependencies>\n    <dependency>\n      <artifactId>x</artifactId>\n      <version>1.0.0</version>\n    </dependency>\n  </dependencies>\n</project>\n")
+	defer os.Remove(targetFile)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"kind" : "xml",
+						"path" : "/project/version",
+						"replacement" : "{{.version}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, []string{"2.0.0"}, "", Options{}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// The version of the dependency is not changed.
+	after := readString(t, targetFile)
+	if after != "<project>\n  <version>2.0.0</version>\n  <dependencies>\n    <dependency>\n      <artifactId>x</artifactId>\n      <version>1.0.0</version>\n    </dependency>\n  </dependencies>\n</project>\n" {
+		t.Fatal("failed test\n", after)
+	}
+}
+
+func TestRun_tomlKind(t *testing.T) {
+
+	targetFile := createTempFile(t, "[package]\nname = \"app\"\nversion = \"1.0.0\" # the version\n\n[dependencies]\nlib = { version = \"1.0.0\" }\n")
+	defer os.Remove(targetFile)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"kind" : "toml",
+						"path" : "package.version",
+						"replacement" : "{{.version}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, []string{"2.0.0"}, "", Options{}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// The comment is kept, and the version of the dependency is not changed.
+	after := readString(t, targetFile)
+	if after != "[package]\nname = \"app\"\nversion = \"2.0.0\" # the version\n\n[dependencies]\nlib = { version = \"1.0.0\" }\n" {
+		t.Fatal("failed test\n", after)
+	}
+}
+
+func TestRun_propertiesKind(t *testing.T) {
+
+	targetFile := createTempFile(t, "name=example\n#version=0.0.0\nversion=1.0.0\napp.version=1.0.0\n")
+	defer os.Remove(targetFile)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"kind" : "properties",
+						"key" : "version",
+						"replacement" : "{{.version}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, []string{"2.0.0"}, "", Options{}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// The commented-out line and the other keys are not changed.
+	after := readString(t, targetFile)
+	if after != "name=example\n#version=0.0.0\nversion=2.0.0\napp.version=1.0.0\n" {
+		t.Fatal("failed test\n", after)
+	}
+
+	// The embedded values can be read.
+	w = &bytes.Buffer{}
+	err = runGet(configFile, "", Options{}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	output := w.String()
+	expect := fmt.Sprintf(`%s
+  Pattern: version
+    L3: 2.0.0
+      version: 2.0.0
+`, targetFile)
+
+	if output != expect {
+		t.Fatal("failed test\n", output)
+	}
+}
+
+func TestRun_json(t *testing.T) {
+
+	targetFile1 := createTempFile(t, "name=x\nversion=v1.0.0\n")
+	defer os.Remove(targetFile1)
+	targetFile2 := createTempFile(t, `date=2021-11-24`)
+	defer os.Remove(targetFile2)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version",
+				"source" : {
+					"file" : "%s",
+					"pattern" : "version=v(?P<version>.+)"
+				}
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s",
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					}
+				],
+				"onUnchanged" : "warn"
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`),
+		strings.ReplaceAll(targetFile1, `\`, `\\`),
+		strings.ReplaceAll(targetFile2, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, []string{}, "", Options{Bump: "minor", Output: "json"}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	after := readString(t, targetFile1)
+	if after != "name=x\nversion=v1.1.0\n" {
+		t.Fatal("failed test\n", after)
+	}
+
+	output := w.String()
+	expect := fmt.Sprintf(`{
+  "mode": "write",
+  "bumped": {
+    "name": "version",
+    "current": "1.0.0",
+    "next": "1.1.0"
+  },
+  "values": {
+    "version": "1.1.0"
+  },
+  "targets": [
+    {
+      "rules": [
+        {
+          "pattern": "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+          "replacement": "version=v1.1.0"
+        }
+      ],
+      "files": [
+        {
+          "file": "%s",
+          "status": "updated",
+          "matches": [
+            1
+          ],
+          "changedLines": [
+            2
+          ]
+        },
+        {
+          "file": "%s",
+          "status": "unchanged",
+          "matches": [
+            0
+          ],
+          "changedLines": []
+        }
+      ]
+    }
+  ],
+  "warnings": [
+    "%s was not changed"
+  ]
+}
+`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`),
+		strings.ReplaceAll(targetFile2, `\`, `\\`),
+		strings.ReplaceAll(targetFile2, `\`, `\\`))
+
+	if output != expect {
+		t.Fatal("failed test\n", output)
+	}
+}
+
+func TestRun_json_dryRun(t *testing.T) {
+
+	targetFile := createTempFile(t, "version=v1.0.0")
+	defer os.Remove(targetFile)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, []string{"2.0.0"}, "", Options{DryRun: true, Output: "json"}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// not changed
+	before := readString(t, targetFile)
+	if before != "version=v1.0.0" {
+		t.Fatal("failed test\n", before)
+	}
+
+	output := w.String()
+	expect := fmt.Sprintf(`{
+  "mode": "dry-run",
+  "values": {
+    "version": "2.0.0"
+  },
+  "targets": [
+    {
+      "rules": [
+        {
+          "pattern": "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+          "replacement": "version=v2.0.0"
+        }
+      ],
+      "files": [
+        {
+          "file": "%s",
+          "status": "updated",
+          "matches": [
+            1
+          ],
+          "changedLines": [
+            1
+          ],
+          "diff": "--- %s\n+++ %s\n@@ -1 +1 @@\n-version=v1.0.0\n\\ No newline at end of file\n+version=v2.0.0\n\\ No newline at end of file\n"
+        }
+      ]
+    }
+  ],
+  "warnings": []
+}
+`,
+		strings.ReplaceAll(targetFile, `\`, `\\`),
+		strings.ReplaceAll(targetFile, `\`, `\\`),
+		strings.ReplaceAll(targetFile, `\`, `\\`))
+
+	if output != expect {
+		t.Fatal("failed test\n", output)
+	}
+}
+
+func TestRun_json_check(t *testing.T) {
+
+	targetFile := createTempFile(t, "version=v1.0.0\nname=x\nversion=v2.0.0\n")
+	defer os.Remove(targetFile)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := run(configFile, []string{"2.0.0"}, "", Options{Check: true, Output: "json"}, w)
+	if err == nil || err.Error() != "1 file(s) are out of date" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	output := w.String()
+	expect := fmt.Sprintf(`{
+  "mode": "check",
+  "values": {
+    "version": "2.0.0"
+  },
+  "targets": [
+    {
+      "rules": [
+        {
+          "pattern": "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+          "replacement": "version=v2.0.0"
+        }
+      ],
+      "files": [
+        {
+          "file": "%s",
+          "status": "updated",
+          "matches": [
+            2
+          ],
+          "changedLines": [
+            1
+          ]
+        }
+      ]
+    }
+  ],
+  "warnings": []
+}
+`,
+		strings.ReplaceAll(targetFile, `\`, `\\`))
+
+	if output != expect {
+		t.Fatal("failed test\n", output)
+	}
+}
+
+func TestRun_invalidOutput(t *testing.T) {
+
+	w := &bytes.Buffer{}
+	err := run("emv.json", []string{}, "", Options{Output: "xml"}, w)
+	if err == nil || err.Error() != "'xml' in --output is an invalid value, it must be text or json" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
 func TestRun_loadConfigError(t *testing.T) {
 
 	args := []string{}
@@ -305,7 +1436,7 @@ func TestRun_loadConfigError(t *testing.T) {
 	defer os.Remove(configFile)
 
 	w := &bytes.Buffer{}
-	err := run(configFile, args, "", w)
+	err := run(configFile, args, "", Options{}, w)
 	if err.Error() != "failed to load the config file: unexpected end of JSON input" {
 		t.Fatalf("failed test\n%+v", err)
 	}
@@ -350,8 +1481,8 @@ func TestRun_valuesError(t *testing.T) {
 	defer os.Remove(configFile)
 
 	w := &bytes.Buffer{}
-	err := run(configFile, args, "", w)
-	if err.Error() != "argument must be 2 arguments" {
+	err := run(configFile, args, "", Options{}, w)
+	if err.Error() != "missing values: val2" {
 		t.Fatalf("failed test\n%+v", err)
 	}
 }
@@ -392,7 +1523,7 @@ func TestRun_buildReplaceRulesError(t *testing.T) {
 	defer os.Remove(configFile)
 
 	w := &bytes.Buffer{}
-	err := run(configFile, args, "", w)
+	err := run(configFile, args, "", Options{}, w)
 	if err.Error() != "'version=v[0-9' in embeddeds-pattern is an invalid value: error parsing regexp: missing closing ]: `[0-9`" {
 		t.Fatalf("failed test\n%+v", err)
 	}
@@ -435,7 +1566,7 @@ func TestRun_replaceError(t *testing.T) {
 	defer os.Remove(configFile)
 
 	w := &bytes.Buffer{}
-	err := run(configFile, args, "", w)
+	err := run(configFile, args, "", Options{}, w)
 	pathErr := errors.Cause(err).(*os.PathError)
 	if pathErr.Path != targetFile1+"xxxx" || pathErr.Op != "open" {
 		t.Fatal("failed test\n", err)
@@ -478,130 +1609,35 @@ func TestRun_executeTemplateError(t *testing.T) {
 	defer os.Remove(configFile)
 
 	w := &bytes.Buffer{}
-	err := run(configFile, args, "", w)
-	if err.Error() != "'version=v{{.val1}' in embeddeds-replacement is an invalid value: template: template:1: unexpected \"}\" in operand" {
-		t.Fatalf("failed test\n%+v", err)
-	}
-}
-
-func TestReplace(t *testing.T) {
-
-	contents := "version: 1, date: 2021-12-14"
-
-	file := createTempFile(t, contents)
-	defer os.Remove(file)
-
-	replaceRules := []ReplaceRule{
-		{
-			Regex:       regexp.MustCompile(`version: ([0-9]+)`),
-			Replacement: "version: 2",
-		},
-		{
-			Regex:       regexp.MustCompile(`date: ([0-9\-]+)`),
-			Replacement: "date: 2021-12-24",
-		},
-	}
-
-	result, err := replace(file, replaceRules)
-	if err != nil {
+	err := run(configFile, args, "", Options{}, w)
+	if err.Error() != "'version=v{{.val1}' in embeddeds-replacement is an invalid value: template: template:1: bad character U+007D '}'" {
 		t.Fatalf("failed test\n%+v", err)
 	}
-
-	if !result {
-		t.Fatal("failed test\n", result)
-	}
-
-	before := readString(t, file)
-	if before != "version: 2, date: 2021-12-24" {
-		t.Fatal("failed test\n", before)
-	}
-}
-
-func TestBuildReplaceRules(t *testing.T) {
-
-	embeddeds := []Embedded{
-		{
-			Pattern:     "val1=(.+)",
-			Replacement: "val1={{.val1}}",
-		},
-		{
-			Pattern:     "val2=(.+)",
-			Replacement: "val2={{.val2}}",
-		},
-	}
-
-	values := map[string]string{
-		"val1": "a",
-		"val2": "b",
-	}
-
-	result, err := buildReplaceRules(embeddeds, values)
-	if err != nil {
-		t.Fatalf("failed test\n%+v", err)
-	}
-
-	expect := []ReplaceRule{
-		{
-			Regex:       regexp.MustCompile("val1=(.+)"),
-			Replacement: "val1=a",
-		},
-		{
-			Regex:       regexp.MustCompile("val2=(.+)"),
-			Replacement: "val2=b",
-		},
-	}
-
-	if !reflect.DeepEqual(result, expect) {
-		t.Fatal("failed test\n", result)
-	}
 }
 
-func TestExecuteTemplate(t *testing.T) {
-
-	values := map[string]string{
-		"val1": "a",
-		"val2": "b",
-	}
-
-	templStr := "val1={{.val1}}, val2={{.val2}}"
-
-	result, err := executeTemplate(templStr, values)
-	if err != nil {
-		t.Fatalf("failed test\n%+v", err)
-	}
-
-	if result != "val1=a, val2=b" {
-		t.Fatal("failed test\n", result)
-	}
-}
+func TestNamedValues(t *testing.T) {
 
-func TestValues(t *testing.T) {
+	valuesFile := createTempFile(t, `{"version": "1.0.0", "build": 12, "release": true, "date": "2021-12-24"}`)
+	defer os.Remove(valuesFile)
 
-	args := []string{
-		"10.0.3",
-		"x",
-	}
-	valueConfigs := []Value{
-		{
-			Name:    "version",
-			Pattern: "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)\\.(?P<revision>[0-9]+)$",
-		},
-		{
-			Name: "val2",
-		},
+	sets := []string{
+		"version=2.0.0",
+		"note=a=b",
+		"empty=",
 	}
 
-	result, err := values(args, valueConfigs)
+	result, err := namedValues(sets, valuesFile)
 	if err != nil {
 		t.Fatalf("failed test\n%+v", err)
 	}
 
 	expect := map[string]string{
-		"version":  "10.0.3",
-		"major":    "10",
-		"minor":    "0",
-		"revision": "3",
-		"val2":     "x",
+		"version": "2.0.0",
+		"build":   "12",
+		"release": "true",
+		"date":    "2021-12-24",
+		"note":    "a=b",
+		"empty":   "",
 	}
 
 	if !reflect.DeepEqual(result, expect) {
@@ -609,183 +1645,25 @@ func TestValues(t *testing.T) {
 	}
 }
 
-func TestValues_invalidRegex(t *testing.T) {
-
-	args := []string{
-		"10.0.3",
-	}
-	valueConfigs := []Value{
-		{
-			Name:    "version",
-			Pattern: "^(",
-		},
-	}
-
-	_, err := values(args, valueConfigs)
-	if err.Error() != "'^(' in values-pattern is an invalid value: error parsing regexp: missing closing ): `^(`" {
-		t.Fatalf("failed test\n%+v", err)
-	}
-}
-
-func TestValues_unmatchRegex(t *testing.T) {
-
-	args := []string{
-		"10.0.3",
-	}
-	valueConfigs := []Value{
-		{
-			Name:    "version",
-			Pattern: "^[0-9]+$",
-		},
-	}
-
-	_, err := values(args, valueConfigs)
-	if err.Error() != "'10.0.3' does not match the pattern: ^[0-9]+$" {
-		t.Fatalf("failed test\n%+v", err)
-	}
-}
-
-func TestLoadConfig(t *testing.T) {
-
-	config := `
-{
-    "values" : [
-        { 
-            "name" : "version",
-            "pattern" : "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)\\.(?P<revision>[0-9]+)$"
-        },
-        {
-            "name" : "value2"
-        }
-    ],
-    "targets" : [
-        {
-            "files" : [
-                "version.properties",
-                "version2.properties"
-            ],
-            "embeddeds" : [
-                {
-                    "pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
-                    "replacement" : "version=v{{.version}}"
-                }
-            ]
-        },
-        {
-            "files" : [
-                "version.xml"
-            ],
-            "embeddeds" : [
-                {
-                    "pattern" : "<major>[0-9]+</major>",
-                    "replacement" : "<major>{{.major}}</major>"
-                },
-                {
-                    "pattern" : "<minor>[0-9]+</minor>",
-                    "replacement" : "<minor>{{.major}}</minor>"
-                },
-                {
-                    "pattern" : "<revision>[0-9]+</revision>",
-                    "replacement" : "<revision>{{.revision}}</revision>"
-                }
-            ]
-        }
-    ]
-}
-`
-
-	file := createTempFile(t, config)
-	defer os.Remove(file)
+func TestNamedValues_invalidSet(t *testing.T) {
 
-	result, err := loadConfig(file)
-	if err != nil {
+	_, err := namedValues([]string{"version"}, "")
+	if err.Error() != "'version' in --set must be in the form name=value" {
 		t.Fatalf("failed test\n%+v", err)
 	}
-
-	expect := &Config{
-		Values: []Value{
-			{
-				Name:    "version",
-				Pattern: "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)\\.(?P<revision>[0-9]+)$",
-			},
-			{
-				Name: "value2",
-			},
-		},
-		Targets: []Target{
-			{
-				Files: []string{
-					"version.properties",
-					"version2.properties",
-				},
-				Embeddeds: []Embedded{
-					{
-						Pattern:     "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
-						Replacement: "version=v{{.version}}",
-					},
-				},
-			},
-			{
-				Files: []string{
-					"version.xml",
-				},
-				Embeddeds: []Embedded{
-					{
-						Pattern:     "<major>[0-9]+</major>",
-						Replacement: "<major>{{.major}}</major>",
-					},
-					{
-						Pattern:     "<minor>[0-9]+</minor>",
-						Replacement: "<minor>{{.major}}</minor>",
-					},
-					{
-						Pattern:     "<revision>[0-9]+</revision>",
-						Replacement: "<revision>{{.revision}}</revision>",
-					},
-				},
-			},
-		},
-	}
-
-	if !reflect.DeepEqual(result, expect) {
-		t.Fatal("failed test\n", result)
-	}
 }
 
-func TestLoadConfig_invalidFormat(t *testing.T) {
-
-	config := `
-{
-    "value" : [
-        { 
-            "name" : "version",
-            "pattern" : "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)\\.(?P<revision>[0-9]+)$"
-        }
-    ]
-}
-`
+func TestNamedValues_invalidValuesFile(t *testing.T) {
 
-	file := createTempFile(t, config)
-	defer os.Remove(file)
+	valuesFile := createTempFile(t, `{"version": ["1.0.0"]}`)
+	defer os.Remove(valuesFile)
 
-	_, err := loadConfig(file)
-	if err.Error() != "invalid format" {
+	_, err := namedValues(nil, valuesFile)
+	if err.Error() != "'version' in the values file must be a string, number or boolean" {
 		t.Fatalf("failed test\n%+v", err)
 	}
 }
 
-func TestLoadConfig_fileNotfound(t *testing.T) {
-
-	file := createTempFile(t, "")
-	defer os.Remove(file)
-
-	_, err := loadConfig(file + "xxxx")
-	pathErr := errors.Cause(err).(*os.PathError)
-	if pathErr.Path != file+"xxxx" || pathErr.Op != "open" {
-		t.Fatal("failed test\n", err)
-	}
-}
-
 func createTempFile(t *testing.T, content string) string {
 
 	tempFile, err := os.CreateTemp("", "")
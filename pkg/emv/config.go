@@ -0,0 +1,136 @@
+package emv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Values  []Value  `json:"values"`
+	Targets []Target `json:"targets"`
+	Escape  string   `json:"escape"`
+}
+
+type Value struct {
+	Name     string  `json:"name"`
+	Pattern  string  `json:"pattern"`
+	Default  string  `json:"default"`
+	Env      string  `json:"env"`
+	Required *bool   `json:"required"`
+	Source   *Source `json:"source"`
+}
+
+type Source struct {
+	File    string `json:"file"`
+	Pattern string `json:"pattern"`
+}
+
+type Target struct {
+	Files       []string   `json:"files"`
+	Excludes    []string   `json:"excludes"`
+	Embeddeds   []Embedded `json:"embeddeds"`
+	OnUnchanged string     `json:"onUnchanged"`
+}
+
+type Embedded struct {
+	// Kind is regex (default), block, json, yaml, xml, toml, properties, ini or dotenv.
+	Kind        string  `json:"kind"`
+	Pattern     string  `json:"pattern"`
+	Begin       string  `json:"begin"`
+	End         string  `json:"end"`
+	Path        string  `json:"path"`
+	Key         string  `json:"key"`
+	Section     string  `json:"section"`
+	Replacement string  `json:"replacement"`
+	Escape      string  `json:"escape"`
+	Literal     *bool   `json:"literal"`
+	Expect      *Expect `json:"expect"`
+}
+
+// ConfigFileNames are searched in order when the config file is not specified.
+var ConfigFileNames = []string{
+	"emv.json",
+	"emv.yaml",
+	"emv.yml",
+	"emv.toml",
+}
+
+// FindConfigFile returns the first config file found in the directory.
+func FindConfigFile(dirPath string) (string, error) {
+
+	for _, name := range ConfigFileNames {
+		path := filepath.Join(dirPath, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", errors.Errorf("config file not found (%s)", strings.Join(ConfigFileNames, ", "))
+}
+
+// configJSON converts the YAML or TOML config into JSON, so that all formats are mapped onto Config in the same way.
+// The format is selected by the extension, and anything other than YAML and TOML is treated as JSON.
+func configJSON(path string, content []byte) ([]byte, error) {
+
+	var config interface{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(content, &config); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(content, &config); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	default:
+		return content, nil
+	}
+
+	converted, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return converted, nil
+}
+
+// LoadConfig loads the config file. YAML and TOML are selected by the extension.
+func LoadConfig(path string) (*Config, error) {
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	converted, err := configJSON(path, content)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	err = json.Unmarshal(converted, &config)
+	if err != nil {
+		// The error of the converted JSON is hard to read, so the wrong types are reported with the paths in the config.
+		if validationErrors := typeErrors(path, content); len(validationErrors) != 0 {
+			messages := []string{}
+			for _, validationError := range validationErrors {
+				messages = append(messages, validationError.String())
+			}
+			return nil, errors.Errorf("invalid format: %s", strings.Join(messages, ", "))
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	if len(config.Targets) == 0 || len(config.Values) == 0 {
+		return nil, errors.Errorf("invalid format")
+	}
+
+	return &config, nil
+}
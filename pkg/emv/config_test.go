@@ -0,0 +1,348 @@
+package emv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestLoadConfig_yaml(t *testing.T) {
+
+	config := `
+values:
+  - name: version
+    pattern: ^(?P<major>[0-9]+)\.(?P<minor>[0-9]+)\.(?P<revision>[0-9]+)$
+  - name: date
+    required: false
+targets:
+  - files:
+      - version.properties
+    embeddeds:
+      - pattern: version=v[0-9]+\.[0-9]+\.[0-9]+
+        replacement: version=v{{.version}}
+`
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"emv.yaml", "emv.yml"} {
+
+		file := createFile(t, dir, name, config)
+
+		result, err := LoadConfig(file)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		required := false
+		expect := &Config{
+			Values: []Value{
+				{
+					Name:    "version",
+					Pattern: "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)\\.(?P<revision>[0-9]+)$",
+				},
+				{
+					Name:     "date",
+					Required: &required,
+				},
+			},
+			Targets: []Target{
+				{
+					Files: []string{
+						"version.properties",
+					},
+					Embeddeds: []Embedded{
+						{
+							Pattern:     "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+							Replacement: "version=v{{.version}}",
+						},
+					},
+				},
+			},
+		}
+
+		if !reflect.DeepEqual(result, expect) {
+			t.Fatal("failed test\n", result)
+		}
+	}
+}
+
+func TestLoadConfig_toml(t *testing.T) {
+
+	config := `
+escape = "xml"
+
+[[values]]
+name = 'version'
+pattern = '^(?P<major>[0-9]+)\.(?P<minor>[0-9]+)\.(?P<revision>[0-9]+)$'
+
+[[targets]]
+files = ["version.properties"]
+
+[[targets.embeddeds]]
+pattern = 'version=v[0-9]+\.[0-9]+\.[0-9]+'
+replacement = 'version=v{{.version}}'
+`
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := createFile(t, dir, "emv.toml", config)
+
+	result, err := LoadConfig(file)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := &Config{
+		Values: []Value{
+			{
+				Name:    "version",
+				Pattern: "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)\\.(?P<revision>[0-9]+)$",
+			},
+		},
+		Targets: []Target{
+			{
+				Files: []string{
+					"version.properties",
+				},
+				Embeddeds: []Embedded{
+					{
+						Pattern:     "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						Replacement: "version=v{{.version}}",
+					},
+				},
+			},
+		},
+		Escape: "xml",
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestLoadConfig_invalidYaml(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := createFile(t, dir, "emv.yaml", "values: [")
+
+	_, err := LoadConfig(file)
+	if err == nil {
+		t.Fatal("failed test")
+	}
+}
+
+func TestLoadConfig_yamlNumber(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := createFile(t, dir, "emv.yaml", `values:
+  - name: version
+    default: 1.0
+    unknown: x
+targets:
+  - files: [a.txt]
+    embeddeds:
+      - pattern: "version=.+"
+        replacement: 2
+`)
+
+	_, err := LoadConfig(file)
+	if err == nil || err.Error() != "invalid format: $.values[0].default (3:14): must be a string, $.targets[0].embeddeds[0].replacement (9:22): must be a string" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestLoadConfig_tomlNumber(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := createFile(t, dir, "emv.toml", `[[values]]
+name = "version"
+default = 1.0
+
+[[targets]]
+files = ["a.txt"]
+
+[[targets.embeddeds]]
+pattern = "version=.+"
+replacement = "version={{.version}}"
+`)
+
+	_, err := LoadConfig(file)
+	if err == nil || err.Error() != "invalid format: $.values[0].default: must be a string" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	_, err := FindConfigFile(dir)
+	if err == nil || err.Error() != "config file not found (emv.json, emv.yaml, emv.yml, emv.toml)" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	createFile(t, dir, "emv.toml", "")
+	createFile(t, dir, "emv.yml", "")
+
+	result, err := FindConfigFile(dir)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if result != filepath.Join(dir, "emv.yml") {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+
+	config := `
+{
+    "values" : [
+        { 
+            "name" : "version",
+            "pattern" : "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)\\.(?P<revision>[0-9]+)$"
+        },
+        {
+            "name" : "value2"
+        }
+    ],
+    "targets" : [
+        {
+            "files" : [
+                "version.properties",
+                "version2.properties"
+            ],
+            "embeddeds" : [
+                {
+                    "pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+                    "replacement" : "version=v{{.version}}"
+                }
+            ]
+        },
+        {
+            "files" : [
+                "version.xml"
+            ],
+            "embeddeds" : [
+                {
+                    "pattern" : "<major>[0-9]+</major>",
+                    "replacement" : "<major>{{.major}}</major>"
+                },
+                {
+                    "pattern" : "<minor>[0-9]+</minor>",
+                    "replacement" : "<minor>{{.major}}</minor>"
+                },
+                {
+                    "pattern" : "<revision>[0-9]+</revision>",
+                    "replacement" : "<revision>{{.revision}}</revision>"
+                }
+            ]
+        }
+    ]
+}
+`
+
+	file := createTempFile(t, config)
+	defer os.Remove(file)
+
+	result, err := LoadConfig(file)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := &Config{
+		Values: []Value{
+			{
+				Name:    "version",
+				Pattern: "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)\\.(?P<revision>[0-9]+)$",
+			},
+			{
+				Name: "value2",
+			},
+		},
+		Targets: []Target{
+			{
+				Files: []string{
+					"version.properties",
+					"version2.properties",
+				},
+				Embeddeds: []Embedded{
+					{
+						Pattern:     "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						Replacement: "version=v{{.version}}",
+					},
+				},
+			},
+			{
+				Files: []string{
+					"version.xml",
+				},
+				Embeddeds: []Embedded{
+					{
+						Pattern:     "<major>[0-9]+</major>",
+						Replacement: "<major>{{.major}}</major>",
+					},
+					{
+						Pattern:     "<minor>[0-9]+</minor>",
+						Replacement: "<minor>{{.major}}</minor>",
+					},
+					{
+						Pattern:     "<revision>[0-9]+</revision>",
+						Replacement: "<revision>{{.revision}}</revision>",
+					},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestLoadConfig_invalidFormat(t *testing.T) {
+
+	config := `
+{
+    "value" : [
+        { 
+            "name" : "version",
+            "pattern" : "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)\\.(?P<revision>[0-9]+)$"
+        }
+    ]
+}
+`
+
+	file := createTempFile(t, config)
+	defer os.Remove(file)
+
+	_, err := LoadConfig(file)
+	if err.Error() != "invalid format" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestLoadConfig_fileNotfound(t *testing.T) {
+
+	file := createTempFile(t, "")
+	defer os.Remove(file)
+
+	_, err := LoadConfig(file + "xxxx")
+	pathErr := errors.Cause(err).(*os.PathError)
+	if pathErr.Path != file+"xxxx" || pathErr.Op != "open" {
+		t.Fatal("failed test\n", err)
+	}
+}
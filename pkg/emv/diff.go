@@ -0,0 +1,73 @@
+package emv
+
+import (
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+func unifiedDiff(name string, before string, after string) string {
+
+	if before == after {
+		return ""
+	}
+
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(before),
+		B:        diffLines(after),
+		FromFile: name,
+		ToFile:   name,
+		Context:  3,
+	})
+
+	return diff
+}
+
+func diffLines(s string) []string {
+
+	lines := splitLines(s)
+	if len(lines) != 0 && !strings.HasSuffix(s, "\n") {
+		// Mark the missing newline at end of file in the same way as diff(1).
+		lines[len(lines)-1] += "\n\\ No newline at end of file\n"
+	}
+
+	return lines
+}
+
+// changedLines returns the line numbers (1-based) of before that are changed in after.
+func changedLines(before string, after string) []int {
+
+	beforeLines := splitLines(before)
+	matcher := difflib.NewMatcher(beforeLines, splitLines(after))
+
+	lines := []int{}
+	for _, opCode := range matcher.GetOpCodes() {
+		switch opCode.Tag {
+		case 'r', 'd':
+			for i := opCode.I1; i < opCode.I2; i++ {
+				lines = append(lines, i+1)
+			}
+		case 'i':
+			// Only added lines, so it is shown as the line of the insertion position.
+			line := opCode.I1 + 1
+			if line > len(beforeLines) {
+				line = len(beforeLines)
+			}
+			if line > 0 && (len(lines) == 0 || lines[len(lines)-1] != line) {
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	return lines
+}
+
+func splitLines(s string) []string {
+
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+
+	return lines
+}
@@ -0,0 +1,60 @@
+package emv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+
+	result := unifiedDiff("a.txt", "a\nb\nc\n", "a\nB\nc\n")
+
+	expect := `--- a.txt
++++ a.txt
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+`
+	if result != expect {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestUnifiedDiff_noNewlineAtEnd(t *testing.T) {
+
+	result := unifiedDiff("a.txt", "a\nb", "a\nB")
+
+	expect := `--- a.txt
++++ a.txt
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++B
+\ No newline at end of file
+`
+	if result != expect {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestUnifiedDiff_unchanged(t *testing.T) {
+
+	result := unifiedDiff("a.txt", "a\nb\n", "a\nb\n")
+
+	if result != "" {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestChangedLines(t *testing.T) {
+
+	result := changedLines("a\nb\nc\nd\ne", "a\nB\nc\nd\nE")
+
+	expect := []int{2, 5}
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
@@ -0,0 +1,123 @@
+package emv
+
+import (
+	"strings"
+)
+
+// dotenvLocator finds the values of the key in a .env file.
+// The key may be preceded by "export".
+type dotenvLocator struct {
+	key string
+}
+
+func (l *dotenvLocator) locate(content string) ([]place, error) {
+
+	places := []place{}
+
+	for offset := 0; offset < len(content); {
+
+		start := skipSpaces(content, offset, len(content))
+		end, next := lineEnd(content, start)
+		offset = next
+
+		if start == end || content[start] == '#' {
+			continue
+		}
+
+		if strings.HasPrefix(content[start:end], "export ") {
+			start = skipSpaces(content, start+len("export "), end)
+		}
+
+		separator := strings.IndexByte(content[start:end], '=')
+		if separator == -1 {
+			continue
+		}
+
+		key := strings.TrimSpace(content[start : start+separator])
+		valueStart := skipSpaces(content, start+separator+1, end)
+
+		place := dotenvPlace(content, valueStart, end)
+		if place.end > end {
+			// The quoted value continues to the following lines.
+			_, offset = lineEnd(content, place.end)
+		}
+
+		if key == l.key {
+			places = append(places, place)
+		}
+	}
+
+	return places, nil
+}
+
+// dotenvPlace returns the value at the offset. The quotes and the comment after the value are kept.
+func dotenvPlace(content string, start int, end int) place {
+
+	if start < end && (content[start] == '"' || content[start] == '\'') {
+		quote := content[start]
+
+		closing := start + 1
+		for closing < len(content) && content[closing] != quote {
+			if quote == '"' && content[closing] == '\\' {
+				closing++
+			}
+			closing++
+		}
+
+		if closing < len(content) {
+			if quote == '\'' {
+				return place{
+					start: start,
+					end:   closing + 1,
+					text:  content[start+1 : closing],
+					encode: func(replacement string) (string, error) {
+						if strings.Contains(replacement, "'") {
+							return encodeDotenvDoubleQuoted(replacement)
+						}
+						return "'" + replacement + "'", nil
+					},
+				}
+			}
+
+			return place{
+				start:  start,
+				end:    closing + 1,
+				text:   unescapeDotenv(content[start+1 : closing]),
+				encode: encodeDotenvDoubleQuoted,
+			}
+		}
+	}
+
+	// A comment after the value starts with # after spaces.
+	for i := start + 1; i < end; i++ {
+		if content[i] == '#' && (content[i-1] == ' ' || content[i-1] == '\t') {
+			end = i
+			break
+		}
+	}
+	end = trimSpacesEnd(content, start, end)
+
+	return place{
+		start: start,
+		end:   end,
+		text:  content[start:end],
+		encode: func(replacement string) (string, error) {
+			if strings.ContainsAny(replacement, " \t\r\n#\"'\\") {
+				return encodeDotenvDoubleQuoted(replacement)
+			}
+			return replacement, nil
+		},
+	}
+}
+
+var dotenvUnescaper = strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+
+func unescapeDotenv(text string) string {
+	return dotenvUnescaper.Replace(text)
+}
+
+var dotenvEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+
+func encodeDotenvDoubleQuoted(replacement string) (string, error) {
+	return `"` + dotenvEscaper.Replace(replacement) + `"`, nil
+}
@@ -0,0 +1,76 @@
+package emv
+
+import (
+	"testing"
+)
+
+func TestDotenvLocator(t *testing.T) {
+
+	content := `# VERSION=0.0.0
+APP_VERSION=1.0.0
+VERSION=1.0.0 # the version
+export VERSION = "1.0.0\n\"a\""
+VERSION='a
+b'
+OTHER="VERSION=x"
+VERSION=
+`
+
+	places, err := (&dotenvLocator{key: "VERSION"}).locate(content)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expects := []struct {
+		text  string
+		place string
+	}{
+		{"1.0.0", "1.0.0"},
+		{"1.0.0\n\"a\"", `"1.0.0\n\"a\""`},
+		{"a\nb", "'a\nb'"},
+		{"", ""},
+	}
+
+	if len(places) != len(expects) {
+		t.Fatal("failed test\n", places)
+	}
+	for i, expect := range expects {
+		if places[i].text != expect.text || content[places[i].start:places[i].end] != expect.place {
+			t.Fatal("failed test\n", places[i])
+		}
+	}
+}
+
+func TestDotenvLocator_encode(t *testing.T) {
+
+	content := "A=a\nB=\"b\"\nC='c'\n"
+
+	tests := []struct {
+		key         string
+		replacement string
+		expect      string
+	}{
+		{"A", "1.0.0", "1.0.0"},
+		{"A", "a b", `"a b"`},
+		{"A", "a#b", `"a#b"`},
+		{"B", "a\"b\\\nc", `"a\"b\\\nc"`},
+		{"C", "a\"b", `'a"b'`},
+		{"C", "it's", `"it's"`},
+	}
+
+	for _, test := range tests {
+
+		places, err := (&dotenvLocator{key: test.key}).locate(content)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		encoded, err := places[0].encode(test.replacement)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+		if encoded != test.expect {
+			t.Fatalf("failed test\n%s: %s", test.key, encoded)
+		}
+	}
+}
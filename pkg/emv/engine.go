@@ -0,0 +1,62 @@
+// Package emv embeds values in files based on the rules of the config.
+// It is the engine of the emv command.
+package emv
+
+// Engine embeds the values in the target files of the config.
+type Engine struct {
+	Config *Config
+	// BaseDir is the base directory of the target files and the source files.
+	BaseDir string
+	FS      FS
+}
+
+// Bumped is the value bumped to the next version.
+type Bumped struct {
+	Name    string `json:"name"`
+	Current string `json:"current"`
+	Next    string `json:"next"`
+}
+
+// NewEngine returns the engine for the files on the OS file system.
+func NewEngine(config *Config, baseDir string) *Engine {
+
+	return &Engine{
+		Config:  config,
+		BaseDir: baseDir,
+		FS:      OSFS{},
+	}
+}
+
+// Values resolves the values of the config from the arguments, the values specified by name,
+// the environment variables and the defaults.
+// The arguments are assigned in order to the values that are not specified by name.
+func (e *Engine) Values(args []string, namedValues map[string]string) (map[string]string, error) {
+	return values(args, namedValues, e.Config.Values)
+}
+
+// Bump reads the current version from the source of the value, and returns the next version.
+// The part is major, minor, patch or prerelease.
+func (e *Engine) Bump(part string) (*Bumped, error) {
+
+	name, current, next, err := bump(e.FS, e.Config.Values, part, e.BaseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bumped{
+		Name:    name,
+		Current: current,
+		Next:    next,
+	}, nil
+}
+
+// Plan computes the contents of all target files with the values, without writing them.
+func (e *Engine) Plan(values map[string]string) (*Plan, error) {
+	return plan(e.FS, e.Config, values, e.BaseDir)
+}
+
+// Apply writes the files changed in the plan.
+// Either all files are written, or none of them.
+func (e *Engine) Apply(plan *Plan) error {
+	return writeFiles(e.FS, plan.writes)
+}
@@ -0,0 +1,98 @@
+package emv
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"html"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// escapers are the functions to escape the values according to the format of the target file.
+var escapers = map[string]func(string) string{
+	"":      escapeNone,
+	"none":  escapeNone,
+	"html":  template.HTMLEscapeString,
+	"xml":   escapeXML,
+	"json":  escapeJSON,
+	"yaml":  escapeYAML,
+	"shell": escapeShell,
+	"regex": regexp.QuoteMeta,
+}
+
+func escapeNone(s string) string {
+	return s
+}
+
+func escapeXML(s string) string {
+
+	w := &bytes.Buffer{}
+	// Writing to bytes.Buffer does not fail.
+	_ = xml.EscapeText(w, []byte(s))
+
+	return w.String()
+}
+
+// escapeJSON escapes the value to be embedded in a JSON string. (The surrounding double quotes are not added.)
+func escapeJSON(s string) string {
+
+	w := &bytes.Buffer{}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	// Encoding a string does not fail.
+	_ = encoder.Encode(s)
+
+	quoted := strings.TrimSuffix(w.String(), "\n")
+	return quoted[1 : len(quoted)-1]
+}
+
+// escapeYAML escapes the value to be embedded in a YAML double-quoted scalar. (The surrounding double quotes are not added.)
+func escapeYAML(s string) string {
+	// YAML double-quoted scalars accept the JSON escape sequences.
+	return escapeJSON(s)
+}
+
+// escapeShell quotes the value with single quotes so that it is a single word in POSIX shells.
+func escapeShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// unescapers are the reverse of escapers, used to read the embedded values.
+var unescapers = map[string]func(string) string{
+	"":      escapeNone,
+	"none":  escapeNone,
+	"html":  html.UnescapeString,
+	"xml":   html.UnescapeString,
+	"json":  unescapeJSON,
+	"yaml":  unescapeJSON,
+	"shell": unescapeShell,
+	"regex": unescapeRegex,
+}
+
+func unescapeJSON(s string) string {
+
+	var value string
+	if err := json.Unmarshal([]byte(`"`+s+`"`), &value); err != nil {
+		// It is not escaped as JSON, so it is returned as it is.
+		return s
+	}
+
+	return value
+}
+
+func unescapeShell(s string) string {
+
+	if len(s) >= 2 && strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
+		return strings.ReplaceAll(s[1:len(s)-1], `'\''`, "'")
+	}
+
+	return s
+}
+
+var regexEscapeRegexp = regexp.MustCompile(`\\(.)`)
+
+func unescapeRegex(s string) string {
+	return regexEscapeRegexp.ReplaceAllString(s, "$1")
+}
@@ -0,0 +1,39 @@
+package emv
+
+import (
+	"testing"
+)
+
+func TestEscapers(t *testing.T) {
+
+	value := `a<b>&'c' "d"\e$f.*`
+
+	tests := []struct {
+		escape string
+		expect string
+	}{
+		{"", `a<b>&'c' "d"\e$f.*`},
+		{"none", `a<b>&'c' "d"\e$f.*`},
+		{"html", `a&lt;b&gt;&amp;&#39;c&#39; &#34;d&#34;\e$f.*`},
+		{"xml", `a&lt;b&gt;&amp;&#39;c&#39; &#34;d&#34;\e$f.*`},
+		{"json", `a<b>&'c' \"d\"\\e$f.*`},
+		{"yaml", `a<b>&'c' \"d\"\\e$f.*`},
+		{"shell", `'a<b>&'\''c'\'' "d"\e$f.*'`},
+		{"regex", `a<b>&'c' "d"\\e\$f\.\*`},
+	}
+
+	for _, test := range tests {
+		result := escapers[test.escape](value)
+		if result != test.expect {
+			t.Fatalf("failed test\n%s: %s", test.escape, result)
+		}
+	}
+}
+
+func TestEscapeJSON_controlCharacter(t *testing.T) {
+
+	result := escapeJSON("a\nb\tc")
+	if result != `a\nb\tc` {
+		t.Fatal("failed test\n", result)
+	}
+}
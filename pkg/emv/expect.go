@@ -0,0 +1,65 @@
+package emv
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+type Expect struct {
+	Count *int `json:"count"`
+	Min   *int `json:"min"`
+	Max   *int `json:"max"`
+}
+
+// check returns an error if the number of matches is not as expected.
+func (e *Expect) check(count int) error {
+
+	if e == nil {
+		return nil
+	}
+
+	if (e.Count != nil && count != *e.Count) ||
+		(e.Min != nil && count < *e.Min) ||
+		(e.Max != nil && count > *e.Max) {
+		return errors.Errorf("matched %d times, but expected %s", count, e)
+	}
+
+	return nil
+}
+
+func (e *Expect) String() string {
+
+	switch {
+	case e.Count != nil:
+		return fmt.Sprintf("exactly %d", *e.Count)
+	case e.Min != nil && e.Max != nil:
+		return fmt.Sprintf("%d to %d", *e.Min, *e.Max)
+	case e.Min != nil:
+		return fmt.Sprintf("at least %d", *e.Min)
+	case e.Max != nil:
+		return fmt.Sprintf("at most %d", *e.Max)
+	default:
+		return "any number"
+	}
+}
+
+// validate checks that the settings are consistent.
+func (e *Expect) validate() error {
+
+	if e.Count != nil && (e.Min != nil || e.Max != nil) {
+		return errors.New("count cannot be used with min or max")
+	}
+
+	for _, n := range []*int{e.Count, e.Min, e.Max} {
+		if n != nil && *n < 0 {
+			return errors.New("must not be negative")
+		}
+	}
+
+	if e.Min != nil && e.Max != nil && *e.Min > *e.Max {
+		return errors.New("min must not be greater than max")
+	}
+
+	return nil
+}
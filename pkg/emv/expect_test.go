@@ -0,0 +1,69 @@
+package emv
+
+import (
+	"testing"
+)
+
+func TestExpectCheck(t *testing.T) {
+
+	one := 1
+	three := 3
+
+	tests := []struct {
+		expect *Expect
+		count  int
+		err    string
+	}{
+		{nil, 0, ""},
+		{&Expect{}, 5, ""},
+		{&Expect{Count: &one}, 1, ""},
+		{&Expect{Count: &one}, 0, "matched 0 times, but expected exactly 1"},
+		{&Expect{Count: &one}, 2, "matched 2 times, but expected exactly 1"},
+		{&Expect{Min: &one}, 1, ""},
+		{&Expect{Min: &one}, 0, "matched 0 times, but expected at least 1"},
+		{&Expect{Max: &three}, 3, ""},
+		{&Expect{Max: &three}, 4, "matched 4 times, but expected at most 3"},
+		{&Expect{Min: &one, Max: &three}, 2, ""},
+		{&Expect{Min: &one, Max: &three}, 4, "matched 4 times, but expected 1 to 3"},
+	}
+
+	for _, test := range tests {
+		err := test.expect.check(test.count)
+		if test.err == "" {
+			if err != nil {
+				t.Fatalf("failed test\n%+v", err)
+			}
+		} else if err == nil || err.Error() != test.err {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+}
+
+func TestExpectValidate(t *testing.T) {
+
+	one := 1
+	three := 3
+	minus := -1
+
+	tests := []struct {
+		expect *Expect
+		err    string
+	}{
+		{&Expect{Count: &one}, ""},
+		{&Expect{Min: &one, Max: &three}, ""},
+		{&Expect{Count: &one, Max: &three}, "count cannot be used with min or max"},
+		{&Expect{Min: &minus}, "must not be negative"},
+		{&Expect{Min: &three, Max: &one}, "min must not be greater than max"},
+	}
+
+	for _, test := range tests {
+		err := test.expect.validate()
+		if test.err == "" {
+			if err != nil {
+				t.Fatalf("failed test\n%+v", err)
+			}
+		} else if err == nil || err.Error() != test.err {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package emv
+
+import (
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/pkg/errors"
+)
+
+type ExtractRule struct {
+	locator     locator
+	Replacement *TemplateRegexp
+	Unescape    func(string) string
+}
+
+// TemplateRegexp is a regular expression to extract the values from the text generated by a template.
+type TemplateRegexp struct {
+	Regex *regexp.Regexp
+	// Names are the value names for each group of Regex.
+	Names []string
+}
+
+type FileExtraction struct {
+	File  string       `json:"file"`
+	Rules []Extraction `json:"rules"`
+}
+
+type Extraction struct {
+	Kind        string       `json:"kind,omitempty"`
+	Pattern     string       `json:"pattern"`
+	Replacement string       `json:"replacement"`
+	Matches     []MatchValue `json:"matches"`
+}
+
+type MatchValue struct {
+	Line   int               `json:"line"`
+	Text   string            `json:"text"`
+	Values map[string]string `json:"values"`
+	// Conflicts are the other values of the names that appear more than once in the replacement
+	// and are captured with different values.
+	Conflicts map[string][]string `json:"conflicts,omitempty"`
+}
+
+func buildExtractRules(embeddeds []Embedded, defaultEscape string) ([]ExtractRule, error) {
+
+	extractRules := []ExtractRule{}
+
+	for _, emembedded := range embeddeds {
+
+		locator, err := newLocator(emembedded)
+		if err != nil {
+			return nil, err
+		}
+
+		templStr := emembedded.Replacement
+		if emembedded.Kind == "block" {
+			// The newline at the end of the block is not included in the text.
+			templStr = strings.TrimSuffix(templStr, "\n")
+		}
+
+		replacement, err := reverseTemplate(templStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "'%s' in embeddeds-replacement is an invalid value", emembedded.Replacement)
+		}
+
+		escape := emembedded.Escape
+		if escape == "" {
+			escape = defaultEscape
+		}
+
+		unescape, ok := unescapers[escape]
+		if !ok {
+			return nil, errors.Errorf("'%s' in escape is an invalid value", escape)
+		}
+
+		extractRules = append(extractRules, ExtractRule{
+			locator:     locator,
+			Replacement: replacement,
+			Unescape:    unescape,
+		})
+	}
+
+	return extractRules, nil
+}
+
+// extract finds the places of the rule in the content and extracts the values embedded in them.
+func extract(content string, extractRule ExtractRule) ([]MatchValue, error) {
+
+	places, err := extractRule.locator.locate(content)
+	if err != nil {
+		return nil, err
+	}
+
+	matchValues := []MatchValue{}
+
+	for _, place := range places {
+
+		values := map[string]string{}
+		var conflicts map[string][]string
+		if match := extractRule.Replacement.Regex.FindStringSubmatch(place.text); match != nil {
+			for i, name := range extractRule.Replacement.Names {
+				value := extractRule.Unescape(match[i+1])
+
+				first, ok := values[name]
+				if !ok {
+					values[name] = value
+					continue
+				}
+
+				if value != first && !containsString(conflicts[name], value) {
+					if conflicts == nil {
+						conflicts = map[string][]string{}
+					}
+					conflicts[name] = append(conflicts[name], value)
+				}
+			}
+		}
+
+		matchValues = append(matchValues, MatchValue{
+			Line:      lineNumber(content, place.start),
+			Text:      place.text,
+			Values:    values,
+			Conflicts: conflicts,
+		})
+	}
+
+	return matchValues, nil
+}
+
+// reverseTemplate builds a regular expression matching the text generated by the template,
+// in which each {{.name}} is a group.
+func reverseTemplate(templStr string) (*TemplateRegexp, error) {
+
+	templ, err := template.New("template").Funcs(templateFuncs).Parse(templStr)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	pattern := &strings.Builder{}
+	names := []string{}
+
+	pattern.WriteString("(?s)^")
+	if templ.Tree != nil {
+		for _, node := range templ.Tree.Root.Nodes {
+			switch node := node.(type) {
+			case *parse.TextNode:
+				pattern.WriteString(regexp.QuoteMeta(string(node.Text)))
+			case *parse.ActionNode:
+				if name := fieldName(node.Pipe); name != "" {
+					pattern.WriteString("(.*?)")
+					names = append(names, name)
+				} else {
+					pattern.WriteString("(?:.*?)")
+				}
+			default:
+				pattern.WriteString("(?:.*?)")
+			}
+		}
+	}
+	pattern.WriteString("$")
+
+	return &TemplateRegexp{
+		Regex: regexp.MustCompile(pattern.String()),
+		Names: names,
+	}, nil
+}
+
+// fieldName returns the name if the pipeline is a simple field reference such as {{.name}}.
+func fieldName(pipe *parse.PipeNode) string {
+
+	if len(pipe.Decl) != 0 || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return ""
+	}
+
+	field, ok := pipe.Cmds[0].Args[0].(*parse.FieldNode)
+	if !ok || len(field.Ident) != 1 {
+		return ""
+	}
+
+	return field.Ident[0]
+}
+
+// Extract reads the values currently embedded in the target files.
+func (e *Engine) Extract() ([]FileExtraction, error) {
+
+	fileExtractions := []FileExtraction{}
+
+	for _, target := range e.Config.Targets {
+
+		extractRules, err := buildExtractRules(target.Embeddeds, e.Config.Escape)
+		if err != nil {
+			return nil, err
+		}
+
+		files, _, err := targetFiles(e.FS, target.Files, target.Excludes, e.BaseDir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+
+			content, err := e.FS.ReadFile(file.Path)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			extractions := []Extraction{}
+			for i, extractRule := range extractRules {
+
+				matches, err := extract(string(content), extractRule)
+				if err != nil {
+					embedded := target.Embeddeds[i]
+					return nil, errors.Wrapf(err, "'%s' in embeddeds-%s for %s", embedded.location(), locationField(embedded.Kind), file.Name)
+				}
+
+				extractions = append(extractions, Extraction{
+					Kind:        target.Embeddeds[i].Kind,
+					Pattern:     target.Embeddeds[i].location(),
+					Replacement: target.Embeddeds[i].Replacement,
+					Matches:     matches,
+				})
+			}
+
+			fileExtractions = append(fileExtractions, FileExtraction{
+				File:  file.Name,
+				Rules: extractions,
+			})
+		}
+	}
+
+	return fileExtractions, nil
+}
+
+func containsString(texts []string, text string) bool {
+
+	for _, t := range texts {
+		if t == text {
+			return true
+		}
+	}
+
+	return false
+}
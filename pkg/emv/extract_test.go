@@ -0,0 +1,161 @@
+package emv
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestReverseTemplate(t *testing.T) {
+
+	result, err := reverseTemplate(`version={{.version}}.{{.build}} ({{ .version | printf "%s" }}) {{if .x}}y{{end}}`)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if result.Regex.String() != `(?s)^version=(.*?)\.(.*?) \((?:.*?)\) (?:.*?)$` {
+		t.Fatal("failed test\n", result.Regex.String())
+	}
+
+	if !reflect.DeepEqual(result.Names, []string{"version", "build"}) {
+		t.Fatal("failed test\n", result.Names)
+	}
+}
+
+func TestReverseTemplate_invalid(t *testing.T) {
+
+	_, err := reverseTemplate(`version={{.version`)
+	if err == nil {
+		t.Fatal("failed test")
+	}
+}
+
+func TestExtract(t *testing.T) {
+
+	content := "a\nversion=1.0.0-1\nb\nversion=2.0.0-3"
+
+	extractRule := ExtractRule{
+		locator: &regexLocator{regex: regexp.MustCompile(`version=[0-9\.\-]+`)},
+		Replacement: &TemplateRegexp{
+			Regex: regexp.MustCompile(`(?s)^version=(.*?)\-(.*?)$`),
+			Names: []string{"version", "build"},
+		},
+		Unescape: escapeNone,
+	}
+
+	result, err := extract(content, extractRule)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []MatchValue{
+		{
+			Line: 2,
+			Text: "version=1.0.0-1",
+			Values: map[string]string{
+				"version": "1.0.0",
+				"build":   "1",
+			},
+		},
+		{
+			Line: 4,
+			Text: "version=2.0.0-3",
+			Values: map[string]string{
+				"version": "2.0.0",
+				"build":   "3",
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestExtract_escaped(t *testing.T) {
+
+	embeddeds := []Embedded{
+		{
+			Pattern:     "<name>.*?</name>",
+			Replacement: "<name>{{.name}}</name>",
+		},
+	}
+
+	extractRules, err := buildExtractRules(embeddeds, "xml")
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	result, err := extract("<name>a&amp;b</name>", extractRules[0])
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []MatchValue{
+		{
+			Line: 1,
+			Text: "<name>a&amp;b</name>",
+			Values: map[string]string{
+				"name": "a&b",
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestExtract_repeatedName(t *testing.T) {
+
+	embeddeds := []Embedded{
+		{
+			Kind:        "block",
+			Begin:       "<!-- begin -->",
+			End:         "<!-- end -->",
+			Replacement: "npm i x@{{.version}}\nyarn add x@{{.version}}\n",
+		},
+	}
+
+	extractRules, err := buildExtractRules(embeddeds, "")
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	result, err := extract("<!-- begin -->\nnpm i x@2.0.0\nyarn add x@1.9.0\n<!-- end -->\n", extractRules[0])
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []MatchValue{
+		{
+			Line: 2,
+			Text: "npm i x@2.0.0\nyarn add x@1.9.0",
+			Values: map[string]string{
+				"version": "2.0.0",
+			},
+			Conflicts: map[string][]string{
+				"version": {"1.9.0"},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestBuildExtractRules_invalidPattern(t *testing.T) {
+
+	embeddeds := []Embedded{
+		{
+			Pattern:     "version=v[0-9",
+			Replacement: "version=v{{.val1}}",
+		},
+	}
+
+	_, err := buildExtractRules(embeddeds, "")
+	if err == nil || err.Error() != "'version=v[0-9' in embeddeds-pattern is an invalid value: error parsing regexp: missing closing ]: `[0-9`" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
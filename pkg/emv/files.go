@@ -0,0 +1,154 @@
+package emv
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/pkg/errors"
+)
+
+type TargetFile struct {
+	Name string
+	Path string
+}
+
+// targetFiles returns the files matching the names and the patterns, except the excluded ones.
+// It also returns the warnings for the patterns whose matches are all excluded.
+func targetFiles(fsys FS, files []string, excludes []string, baseDirPath string) ([]TargetFile, []string, error) {
+
+	for _, exclude := range excludes {
+		if !doublestar.ValidatePattern(filepath.ToSlash(exclude)) {
+			return nil, nil, errors.Errorf("'%s' in excludes is an invalid pattern", exclude)
+		}
+	}
+
+	targetFiles := []TargetFile{}
+	added := map[string]bool{}
+	warnings := []string{}
+
+	// add returns false if the file is excluded.
+	add := func(name string, path string) bool {
+		name = filepath.Clean(name)
+		if isExcluded(name, excludes) {
+			return false
+		}
+
+		if !added[path] {
+			added[path] = true
+			targetFiles = append(targetFiles, TargetFile{
+				Name: name,
+				Path: path,
+			})
+		}
+		return true
+	}
+
+	for _, file := range files {
+
+		if !hasGlobMeta(file) {
+			if !add(file, resolvePath(file, baseDirPath)) {
+				warnings = append(warnings, fmt.Sprintf("'%s' in files is excluded", file))
+			}
+			continue
+		}
+
+		globDirPath := baseDirPath
+		if globDirPath == "" {
+			globDirPath = "."
+		}
+		pattern := filepath.ToSlash(file)
+		if filepath.IsAbs(file) {
+			globDirPath, pattern = doublestar.SplitPattern(pattern)
+			globDirPath = filepath.FromSlash(globDirPath)
+		}
+
+		// A name such as file[1].txt is used as it is if the file exists.
+		literals, err := fsys.Glob(globDirPath, escapeGlobMeta(pattern))
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		if len(literals) != 0 {
+			if !add(file, resolvePath(file, baseDirPath)) {
+				warnings = append(warnings, fmt.Sprintf("'%s' in files is excluded", file))
+			}
+			continue
+		}
+
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, nil, errors.Errorf("'%s' in files is an invalid pattern", file)
+		}
+
+		matches, err := fsys.Glob(globDirPath, pattern)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+
+		if len(matches) == 0 {
+			return nil, nil, errors.Errorf("'%s' in files did not match any file", file)
+		}
+
+		sort.Strings(matches)
+		excluded := 0
+		for _, match := range matches {
+
+			name := filepath.FromSlash(match)
+			if filepath.IsAbs(file) {
+				name = filepath.Join(globDirPath, name)
+			}
+
+			if !add(name, filepath.Join(globDirPath, filepath.FromSlash(match))) {
+				excluded++
+			}
+		}
+
+		if excluded == len(matches) {
+			warnings = append(warnings, fmt.Sprintf("all %d file(s) matching '%s' in files are excluded", len(matches), file))
+		}
+	}
+
+	return targetFiles, warnings, nil
+}
+
+func resolvePath(file string, baseDirPath string) string {
+
+	if !filepath.IsAbs(file) && baseDirPath != "" {
+		return filepath.Join(baseDirPath, file)
+	}
+
+	return filepath.Clean(file)
+}
+
+func isExcluded(name string, excludes []string) bool {
+
+	for _, exclude := range excludes {
+		// The pattern has already been validated, so there is no error.
+		// The pattern is cleaned as well as the name, so that ./a.txt and a.txt are the same.
+		if matched, _ := doublestar.Match(path.Clean(filepath.ToSlash(exclude)), filepath.ToSlash(name)); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasGlobMeta(file string) bool {
+	return strings.ContainsAny(file, "*?[{")
+}
+
+// escapeGlobMeta escapes the pattern to match the name literally.
+func escapeGlobMeta(pattern string) string {
+
+	b := &strings.Builder{}
+	for _, r := range pattern {
+		if strings.ContainsRune(`*?[]{}\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,288 @@
+package emv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTargetFiles(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	createFile(t, dir, "version.properties", "")
+	createFile(t, dir, "modules/a/version.properties", "")
+	createFile(t, dir, "modules/b/version.properties", "")
+	createFile(t, dir, "modules/b/c/version.properties", "")
+	createFile(t, dir, "modules/b/c/other.properties", "")
+	createFile(t, dir, "modules/test/version.properties", "")
+
+	result, _, err := targetFiles(
+		OSFS{},
+		[]string{
+			"version.properties",
+			"modules/**/version.properties",
+			"modules/a/*.properties", // duplicate
+		},
+		[]string{
+			"modules/test/**",
+		},
+		dir)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []TargetFile{
+		{
+			Name: "version.properties",
+			Path: filepath.Join(dir, "version.properties"),
+		},
+		{
+			Name: filepath.Join("modules", "a", "version.properties"),
+			Path: filepath.Join(dir, "modules", "a", "version.properties"),
+		},
+		{
+			Name: filepath.Join("modules", "b", "c", "version.properties"),
+			Path: filepath.Join(dir, "modules", "b", "c", "version.properties"),
+		},
+		{
+			Name: filepath.Join("modules", "b", "version.properties"),
+			Path: filepath.Join(dir, "modules", "b", "version.properties"),
+		},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestTargetFiles_absolutePattern(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	createFile(t, dir, "a/x.txt", "")
+	createFile(t, dir, "b/x.txt", "")
+
+	result, _, err := targetFiles(
+		OSFS{},
+		[]string{
+			filepath.Join(dir, "*", "x.txt"),
+		},
+		[]string{
+			filepath.Join(dir, "b", "*"),
+		},
+		"")
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []TargetFile{
+		{
+			Name: filepath.Join(dir, "a", "x.txt"),
+			Path: filepath.Join(dir, "a", "x.txt"),
+		},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestTargetFiles_literalName(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	createFile(t, dir, "file[1].txt", "")
+	createFile(t, dir, "file1.txt", "")
+	createFile(t, dir, "file[2].txt", "")
+
+	result, _, err := targetFiles(
+		OSFS{},
+		[]string{
+			"file[1].txt",
+			`file\[2\].txt`,
+		},
+		[]string{},
+		dir)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []TargetFile{
+		{
+			Name: "file[1].txt",
+			Path: filepath.Join(dir, "file[1].txt"),
+		},
+		{
+			Name: "file[2].txt",
+			Path: filepath.Join(dir, "file[2].txt"),
+		},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestTargetFiles_excludeCleaned(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	createFile(t, dir, "a.txt", "")
+	createFile(t, dir, "b.txt", "")
+	createFile(t, dir, "c.txt", "")
+
+	result, warnings, err := targetFiles(
+		OSFS{},
+		[]string{
+			"./a.txt",
+			"./b.txt",
+			"c.txt",
+		},
+		[]string{
+			"a.txt",
+			"./c.txt",
+		},
+		dir)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []TargetFile{
+		{
+			Name: "b.txt",
+			Path: filepath.Join(dir, "b.txt"),
+		},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+
+	if !reflect.DeepEqual(warnings, []string{"'./a.txt' in files is excluded", "'c.txt' in files is excluded"}) {
+		t.Fatal("failed test\n", warnings)
+	}
+}
+
+func TestTargetFiles_allExcluded(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	createFile(t, dir, "a/x.txt", "")
+	createFile(t, dir, "a/y.txt", "")
+	createFile(t, dir, "b/x.txt", "")
+
+	result, warnings, err := targetFiles(
+		OSFS{},
+		[]string{
+			"a/*.txt",
+			"b/*.txt",
+		},
+		[]string{
+			"a/**",
+		},
+		dir)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []TargetFile{
+		{
+			Name: filepath.Join("b", "x.txt"),
+			Path: filepath.Join(dir, "b", "x.txt"),
+		},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+
+	if !reflect.DeepEqual(warnings, []string{"all 2 file(s) matching 'a/*.txt' in files are excluded"}) {
+		t.Fatal("failed test\n", warnings)
+	}
+}
+
+func TestTargetFiles_notMatch(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	createFile(t, dir, "a/x.txt", "")
+
+	_, _, err := targetFiles(OSFS{}, []string{"**/*.xml"}, []string{}, dir)
+	if err == nil || err.Error() != "'**/*.xml' in files did not match any file" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestTargetFiles_invalidPattern(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	_, _, err := targetFiles(OSFS{}, []string{"[a-"}, []string{}, dir)
+	if err == nil || err.Error() != "'[a-' in files is an invalid pattern" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	_, _, err = targetFiles(OSFS{}, []string{"a.txt"}, []string{"{a,b"}, dir)
+	if err == nil || err.Error() != "'{a,b' in excludes is an invalid pattern" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func createTempDir(t *testing.T) string {
+
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Fatal("create dir failed\n", err)
+	}
+
+	return dir
+}
+
+func createFile(t *testing.T, dir string, name string, content string) string {
+
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal("create dir failed\n", err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0666); err != nil {
+		t.Fatal("write file failed\n", err)
+	}
+
+	return path
+}
+
+func createTempFile(t *testing.T, content string) string {
+
+	tempFile, err := os.CreateTemp("", "")
+	if err != nil {
+		t.Fatal("craete file failed\n", err)
+	}
+	defer tempFile.Close()
+
+	_, err = tempFile.Write([]byte(content))
+	if err != nil {
+		t.Fatal("write file failed\n", err)
+	}
+
+	return tempFile.Name()
+}
+
+func readString(t *testing.T, file string) string {
+
+	bo, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal("read failed\n", err)
+	}
+
+	return string(bo)
+}
@@ -0,0 +1,129 @@
+package emv
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/pkg/errors"
+)
+
+// FS is the file system to read and write the target files.
+// Unlike io/fs.FS, the names are OS paths, so that the absolute paths in the config can be used as they are.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	// WriteFile replaces the content of the existing file.
+	WriteFile(name string, data []byte) error
+	// Glob returns the files in the directory matching the pattern.
+	// The pattern and the results are slash-separated paths relative to the directory.
+	Glob(dir string, pattern string) ([]string, error)
+}
+
+// OSFS is the FS of the operating system.
+type OSFS struct{}
+
+func (OSFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+// WriteFile writes the file atomically, keeping the permissions of the file.
+func (OSFS) WriteFile(name string, data []byte) error {
+	return writeFileAtomic(name, data)
+}
+
+func (OSFS) Glob(dir string, pattern string) ([]string, error) {
+	return doublestar.Glob(os.DirFS(dir), pattern, doublestar.WithFilesOnly())
+}
+
+// MapFS is an in-memory FS for testing.
+// The keys are the cleaned paths of the files, and the values are the contents.
+type MapFS map[string]string
+
+func (m MapFS) ReadFile(name string) ([]byte, error) {
+
+	content, ok := m[filepath.Clean(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return []byte(content), nil
+}
+
+func (m MapFS) WriteFile(name string, data []byte) error {
+
+	if _, ok := m[filepath.Clean(name)]; !ok {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	m[filepath.Clean(name)] = string(data)
+	return nil
+}
+
+func (m MapFS) Glob(dir string, pattern string) ([]string, error) {
+
+	matches := []string{}
+	for name := range m {
+
+		rel, err := filepath.Rel(filepath.Clean(dir), name)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		matched, err := doublestar.Match(pattern, filepath.ToSlash(rel))
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			matches = append(matches, filepath.ToSlash(rel))
+		}
+	}
+	sort.Strings(matches)
+
+	return matches, nil
+}
+
+// renameFile can be replaced in tests.
+var renameFile = os.Rename
+
+// writeFileAtomic writes the content to a temporary file in the same directory and renames it,
+// so that the file is never left partially written.
+// If the path is a symbolic link, the file it refers to is written and the link is kept.
+func writeFileAtomic(path string, content []byte) error {
+
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".emv-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	tempPath := tempFile.Name()
+
+	_, err = tempFile.Write(content)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempPath, info.Mode().Perm())
+	}
+	if err == nil {
+		err = renameFile(tempPath, path)
+	}
+
+	if err != nil {
+		os.Remove(tempPath)
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package emv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestOSFS_WriteFile(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	file1 := createFile(t, dir, "a.txt", "a")
+	file2 := createFile(t, dir, "b.sh", "b")
+	if err := os.Chmod(file2, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := OSFS{}
+	if err := fsys.WriteFile(file1, []byte("A")); err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+	if err := fsys.WriteFile(file2, []byte("B")); err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if readString(t, file1) != "A" || readString(t, file2) != "B" {
+		t.Fatal("failed test")
+	}
+
+	info, err := os.Stat(file2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Fatal("failed test\n", info.Mode())
+	}
+
+	// temporary files are not left
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatal("failed test\n", entries)
+	}
+}
+
+func TestOSFS_WriteFile_symlink(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := createFile(t, dir, "real/a.txt", "a")
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink(filepath.Join("real", "a.txt"), link); err != nil {
+		t.Skip("symbolic links are not available\n", err)
+	}
+
+	if err := (OSFS{}).WriteFile(link, []byte("A")); err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// the link is kept, and the file it refers to is written
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Fatal("failed test\n", info.Mode())
+	}
+
+	if readString(t, file) != "A" || readString(t, link) != "A" {
+		t.Fatal("failed test")
+	}
+
+	// temporary files are not left
+	entries, err := os.ReadDir(filepath.Join(dir, "real"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatal("failed test\n", entries)
+	}
+}
+
+func TestOSFS_WriteFile_renameError(t *testing.T) {
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := createFile(t, dir, "a.txt", "a")
+
+	defer func() { renameFile = os.Rename }()
+	renameFile = func(oldpath string, newpath string) error {
+		return errors.New("rename failed")
+	}
+
+	err := OSFS{}.WriteFile(file, []byte("A"))
+	if err == nil || err.Error() != "rename failed" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// not changed
+	if readString(t, file) != "a" {
+		t.Fatal("failed test")
+	}
+
+	// temporary files are not left
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatal("failed test\n", entries)
+	}
+}
+
+func TestMapFS(t *testing.T) {
+
+	fsys := MapFS{
+		filepath.Join("dir", "a.txt"):          "a",
+		filepath.Join("dir", "sub", "b.txt"):   "b",
+		filepath.Join("dir", "sub", "c.xml"):   "c",
+		filepath.Join("other", "sub", "d.txt"): "d",
+	}
+
+	{
+		content, err := fsys.ReadFile(filepath.Join("dir", ".", "a.txt"))
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		if string(content) != "a" {
+			t.Fatal("failed test\n", string(content))
+		}
+	}
+	{
+		_, err := fsys.ReadFile("x.txt")
+		if !os.IsNotExist(err) {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		if err := fsys.WriteFile(filepath.Join("dir", "a.txt"), []byte("A")); err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		if fsys[filepath.Join("dir", "a.txt")] != "A" {
+			t.Fatal("failed test\n", fsys)
+		}
+
+		// only existing files
+		err := fsys.WriteFile("x.txt", []byte("X"))
+		if !os.IsNotExist(err) {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		matches, err := fsys.Glob("dir", "**/*.txt")
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		if !reflect.DeepEqual(matches, []string{"a.txt", "sub/b.txt"}) {
+			t.Fatal("failed test\n", matches)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package emv
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// templateFuncs are the functions available in the templates of replacement and default.
+// The functions taking a string take it as the last argument, so that they can be used in pipelines.
+// (e.g. {{.version | trimPrefix "v"}})
+var templateFuncs = template.FuncMap{
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"replace":    replaceString,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": trimPrefix,
+	"trimSuffix": trimSuffix,
+	"default":    defaultValue,
+	"now":        time.Now,
+	"date":       formatDate,
+	"semver":     parseSemver,
+	"sha256":     sha256Hex,
+	"b64enc":     encodeBase64,
+	"b64dec":     decodeBase64,
+}
+
+// replaceString replaces all old in the text with new. (e.g. {{.name | replace "-" "_"}})
+func replaceString(old string, new string, text string) string {
+	return strings.ReplaceAll(text, old, new)
+}
+
+func trimPrefix(prefix string, text string) string {
+	return strings.TrimPrefix(text, prefix)
+}
+
+func trimSuffix(suffix string, text string) string {
+	return strings.TrimSuffix(text, suffix)
+}
+
+// defaultValue returns the default if the value is empty or not defined. (e.g. {{.suffix | default "SNAPSHOT"}})
+func defaultValue(defaultText string, value interface{}) interface{} {
+
+	if value == nil || value == "" {
+		return defaultText
+	}
+
+	return value
+}
+
+// formatDate formats the time with the layout of Go. (e.g. {{now | date "2006-01-02"}})
+func formatDate(layout string, t time.Time) string {
+	return t.Format(layout)
+}
+
+func sha256Hex(text string) string {
+
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+func encodeBase64(text string) string {
+	return base64.StdEncoding.EncodeToString([]byte(text))
+}
+
+func decodeBase64(text string) (string, error) {
+
+	decoded, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "'%s' is not a base64 encoded text", text)
+	}
+
+	return string(decoded), nil
+}
@@ -0,0 +1,95 @@
+package emv
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+
+	values := map[string]string{
+		"name":    "my-app",
+		"version": "v1.2.3-beta.1",
+		"text":    "  abc  ",
+		"empty":   "",
+	}
+
+	tests := []struct {
+		templ  string
+		expect string
+	}{
+		{`{{.name | upper}}`, "MY-APP"},
+		{`{{"ABC" | lower}}`, "abc"},
+		{`{{.name | replace "-" "_"}}`, "my_app"},
+		{`[{{.text | trim}}]`, "[abc]"},
+		{`{{.version | trimPrefix "v"}}`, "1.2.3-beta.1"},
+		{`{{.version | trimSuffix "-beta.1"}}`, "v1.2.3"},
+		{`{{.empty | default "none"}}`, "none"},
+		{`{{.undefined | default "none"}}`, "none"},
+		{`{{.name | default "none"}}`, "my-app"},
+		{`{{(semver .version).Major}}.{{(semver .version).Minor}}.{{(semver .version).Patch}}`, "1.2.3"},
+		{`{{with semver .version}}{{.Prerelease}}{{end}}`, "beta.1"},
+		{`{{.name | sha256}}`, "4c9a75cca717efb68c856f26ba8250737f44e6980d33193c94fa0b8e9cd7618c"},
+		{`{{.name | b64enc}}`, "bXktYXBw"},
+		{`{{"bXktYXBw" | b64dec}}`, "my-app"},
+		{`{{.name | upper | b64enc | b64dec | lower}}`, "my-app"},
+	}
+
+	for _, test := range tests {
+
+		result, err := executeTemplate(test.templ, values)
+		if err != nil {
+			t.Fatalf("failed test\n%s\n%+v", test.templ, err)
+		}
+
+		if result != test.expect {
+			t.Fatalf("failed test\n%s: %s", test.templ, result)
+		}
+	}
+}
+
+func TestTemplateFuncs_date(t *testing.T) {
+
+	result, err := executeTemplate(`{{now | date "2006-01-02"}}`, map[string]string{})
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if _, err := time.Parse("2006-01-02", result); err != nil {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestTemplateFuncs_error(t *testing.T) {
+
+	{
+		_, err := executeTemplate(`{{semver .version}}`, map[string]string{"version": "1.2"})
+		if err == nil || !strings.Contains(err.Error(), "'1.2' is not a semantic version") {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		_, err := executeTemplate(`{{b64dec .value}}`, map[string]string{"value": "a!"})
+		if err == nil || !strings.Contains(err.Error(), "'a!' is not a base64 encoded text") {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+}
+
+func TestTemplateFuncs_defaultUndefined(t *testing.T) {
+
+	templ, err := parseTemplate(`{{.undefined | default "none"}}, {{.match.undefined | default "none"}}`)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	result, err := renderTemplate(templ, templateData(templ, map[string]string{}, map[string]string{}))
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if result != "none, none" {
+		t.Fatal("failed test\n", result)
+	}
+}
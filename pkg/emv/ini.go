@@ -0,0 +1,105 @@
+package emv
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// iniLocator finds the values of the key in the section of an INI file.
+// The keys before the first section are in the section of an empty name.
+type iniLocator struct {
+	section string
+	key     string
+}
+
+func (l *iniLocator) locate(content string) ([]place, error) {
+
+	places := []place{}
+	section := ""
+
+	for offset := 0; offset < len(content); {
+
+		start := skipSpaces(content, offset, len(content))
+		end, next := lineEnd(content, start)
+		offset = next
+
+		if start == end || content[start] == ';' || content[start] == '#' {
+			continue
+		}
+
+		if content[start] == '[' {
+			if index := strings.IndexByte(content[start:end], ']'); index != -1 {
+				section = strings.TrimSpace(content[start+1 : start+index])
+			}
+			continue
+		}
+
+		separator := strings.IndexAny(content[start:end], "=:")
+		if separator == -1 {
+			continue
+		}
+
+		key := strings.TrimSpace(content[start : start+separator])
+		if section != l.section || key != l.key {
+			continue
+		}
+
+		valueStart := skipSpaces(content, start+separator+1, end)
+		places = append(places, iniPlace(content, valueStart, end))
+	}
+
+	return places, nil
+}
+
+// iniPlace returns the value of the line. The quotes and the comment after the value are kept.
+func iniPlace(content string, start int, end int) place {
+
+	end = trimSpacesEnd(content, start, end)
+
+	if start < end && (content[start] == '"' || content[start] == '\'') {
+		quote := content[start]
+		if index := strings.IndexByte(content[start+1:end], quote); index != -1 {
+			return place{
+				start: start,
+				end:   start + 1 + index + 1,
+				text:  content[start+1 : start+1+index],
+				encode: func(replacement string) (string, error) {
+					if err := checkSingleLine(replacement); err != nil {
+						return "", err
+					}
+					return string(quote) + replacement + string(quote), nil
+				},
+			}
+		}
+	}
+
+	// A comment after the value starts with ; or # after spaces.
+	for i := start; i < end; i++ {
+		if (content[i] == ';' || content[i] == '#') && i > start && (content[i-1] == ' ' || content[i-1] == '\t') {
+			end = trimSpacesEnd(content, start, i)
+			break
+		}
+	}
+
+	return place{
+		start: start,
+		end:   end,
+		text:  content[start:end],
+		encode: func(replacement string) (string, error) {
+			if err := checkSingleLine(replacement); err != nil {
+				return "", err
+			}
+			return replacement, nil
+		},
+	}
+}
+
+func checkSingleLine(replacement string) error {
+
+	if strings.ContainsAny(replacement, "\r\n") {
+		return errors.Errorf("'%s' cannot be written in a line", replacement)
+	}
+
+	return nil
+}
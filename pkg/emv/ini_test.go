@@ -0,0 +1,67 @@
+package emv
+
+import (
+	"testing"
+)
+
+func TestINILocator(t *testing.T) {
+
+	content := `version = 0.0.0
+; version = 1.0.0
+[app]
+name = app
+version = 1.0.0 ; the version
+[lib]
+version = 1.0.0
+[ app ]
+version: "1.0.0"
+version = 'a;b' # quoted
+version =
+`
+
+	places, err := (&iniLocator{section: "app", key: "version"}).locate(content)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expects := []struct {
+		text  string
+		place string
+	}{
+		{"1.0.0", "1.0.0"},
+		{"1.0.0", `"1.0.0"`},
+		{"a;b", "'a;b'"},
+		{"", ""},
+	}
+
+	if len(places) != len(expects) {
+		t.Fatal("failed test\n", places)
+	}
+	for i, expect := range expects {
+		if places[i].text != expect.text || content[places[i].start:places[i].end] != expect.place {
+			t.Fatal("failed test\n", places[i])
+		}
+	}
+
+	encoded, err := places[1].encode("2.0.0")
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+	if encoded != `"2.0.0"` {
+		t.Fatal("failed test\n", encoded)
+	}
+
+	_, err = places[0].encode("a\nb")
+	if err == nil || err.Error() != "'a\nb' cannot be written in a line" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// The keys before the first section
+	places, err = (&iniLocator{key: "version"}).locate(content)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+	if len(places) != 1 || places[0].text != "0.0.0" {
+		t.Fatal("failed test\n", places)
+	}
+}
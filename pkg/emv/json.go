@@ -0,0 +1,198 @@
+package emv
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// jsonLocator finds the values of the path in a JSON document.
+// Only the text of the values is replaced, so that the order of the keys and the formatting are kept.
+type jsonLocator struct {
+	path []pathSegment
+}
+
+func (l *jsonLocator) locate(content string) ([]place, error) {
+
+	var raw json.RawMessage
+	if err := json.Unmarshal([]byte(content), &raw); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			return nil, errors.Wrapf(err, "failed to parse as JSON in line %d", lineNumber(content, int(syntaxErr.Offset)))
+		}
+		return nil, errors.Wrap(err, "failed to parse as JSON")
+	}
+
+	parser := &jsonParser{content: content}
+	nodes := []*jsonNode{parser.parseValue()}
+
+	for _, segment := range l.path {
+		children := []*jsonNode{}
+		for _, node := range nodes {
+			children = append(children, node.find(segment)...)
+		}
+		nodes = children
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].start < nodes[j].start })
+
+	places := []place{}
+	for _, node := range nodes {
+
+		isString := node.kind == jsonString
+		places = append(places, place{
+			start: node.start,
+			end:   node.end,
+			text:  node.text,
+			encode: func(replacement string) (string, error) {
+				if isString {
+					return `"` + escapeJSON(replacement) + `"`, nil
+				}
+
+				// The values other than strings are embedded as JSON. (e.g. 2, true, null)
+				if !json.Valid([]byte(replacement)) {
+					return "", errors.Errorf("'%s' is not a valid JSON value", replacement)
+				}
+				return replacement, nil
+			},
+		})
+	}
+
+	return places, nil
+}
+
+type jsonKind int
+
+const (
+	jsonObject jsonKind = iota
+	jsonArray
+	jsonString
+	// jsonLiteral is a number, true, false or null.
+	jsonLiteral
+)
+
+type jsonNode struct {
+	kind  jsonKind
+	start int
+	end   int
+	// text is the decoded string, or the text of the other values.
+	text     string
+	keys     []string
+	children []*jsonNode
+}
+
+// find returns the values of the segment in the object or the array.
+func (n *jsonNode) find(segment pathSegment) []*jsonNode {
+
+	children := []*jsonNode{}
+
+	switch n.kind {
+	case jsonObject:
+		if segment.kind != keySegment {
+			break
+		}
+		for i, key := range n.keys {
+			if key == segment.key {
+				children = append(children, n.children[i])
+			}
+		}
+	case jsonArray:
+		for i, element := range n.children {
+			if segment.matchIndex(i) || (segment.kind == selectSegment && element.field(segment.key) == segment.value) {
+				children = append(children, element)
+			}
+		}
+	}
+
+	return children
+}
+
+// field returns the text of the scalar value of the key, or an empty string if it is not found.
+func (n *jsonNode) field(key string) string {
+
+	if n.kind != jsonObject {
+		return ""
+	}
+
+	for i, k := range n.keys {
+		if k == key && (n.children[i].kind == jsonString || n.children[i].kind == jsonLiteral) {
+			return n.children[i].text
+		}
+	}
+
+	return ""
+}
+
+// jsonParser parses the JSON with the offsets of the values.
+// The content must have been checked as valid JSON.
+type jsonParser struct {
+	content string
+	offset  int
+}
+
+func (p *jsonParser) parseValue() *jsonNode {
+
+	p.skipSpace()
+	node := &jsonNode{start: p.offset}
+
+	switch p.content[p.offset] {
+	case '{':
+		node.kind = jsonObject
+		p.offset++
+		for p.skipSpace(); p.content[p.offset] != '}'; p.skipSpace() {
+			key := p.parseValue()
+			p.skipSpace()
+			p.offset++ // :
+			node.keys = append(node.keys, key.text)
+			node.children = append(node.children, p.parseValue())
+			p.skipDelimiter()
+		}
+		p.offset++
+	case '[':
+		node.kind = jsonArray
+		p.offset++
+		for p.skipSpace(); p.content[p.offset] != ']'; p.skipSpace() {
+			node.children = append(node.children, p.parseValue())
+			p.skipDelimiter()
+		}
+		p.offset++
+	case '"':
+		node.kind = jsonString
+		p.offset++
+		for p.content[p.offset] != '"' {
+			if p.content[p.offset] == '\\' {
+				p.offset++
+			}
+			p.offset++
+		}
+		p.offset++
+		// The string has been checked as valid.
+		_ = json.Unmarshal([]byte(p.content[node.start:p.offset]), &node.text)
+	default:
+		node.kind = jsonLiteral
+		for p.offset < len(p.content) && !strings.ContainsRune(",]} \t\r\n", rune(p.content[p.offset])) {
+			p.offset++
+		}
+		node.text = p.content[node.start:p.offset]
+	}
+
+	node.end = p.offset
+	return node
+}
+
+func (p *jsonParser) skipSpace() {
+
+	for p.offset < len(p.content) && strings.ContainsRune(" \t\r\n", rune(p.content[p.offset])) {
+		p.offset++
+	}
+}
+
+// skipDelimiter skips the comma between the values.
+func (p *jsonParser) skipDelimiter() {
+
+	p.skipSpace()
+	if p.content[p.offset] == ',' {
+		p.offset++
+	}
+}
@@ -0,0 +1,107 @@
+package emv
+
+import (
+	"testing"
+)
+
+func TestJSONLocator(t *testing.T) {
+
+	content := `{
+  "name": "app",
+  "version": "1.0.0",
+  "dependencies": {
+    "mylib": "^1.0.0",
+    "other": { "version": "1.0.0" }
+  },
+  "items": [ 1, { "name": "a", "port": 80 }, { "name": "b", "port": 81 } ],
+  "escaped": "a\"bc",
+  "private": true
+}
+`
+
+	tests := []struct {
+		path   string
+		texts  []string
+		places []string
+	}{
+		{"/version", []string{"1.0.0"}, []string{`"1.0.0"`}},
+		{"$.dependencies.mylib", []string{"^1.0.0"}, []string{`"^1.0.0"`}},
+		{"dependencies.other", []string{""}, []string{`{ "version": "1.0.0" }`}},
+		{"/items/0", []string{"1"}, []string{"1"}},
+		{"items[2].port", []string{"81"}, []string{"81"}},
+		{"items[name=a].port", []string{"80"}, []string{"80"}},
+		{"escaped", []string{`a"bc`}, []string{`"a\"bc"`}},
+		{"private", []string{"true"}, []string{"true"}},
+		{"/none", []string{}, []string{}},
+		{"version.x", []string{}, []string{}},
+	}
+
+	for _, test := range tests {
+
+		path, err := parsePath(test.path)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		places, err := (&jsonLocator{path: path}).locate(content)
+		if err != nil {
+			t.Fatalf("failed test\n%s\n%+v", test.path, err)
+		}
+
+		if len(places) != len(test.texts) {
+			t.Fatalf("failed test\n%s: %v", test.path, places)
+		}
+		for i, place := range places {
+			if place.text != test.texts[i] || content[place.start:place.end] != test.places[i] {
+				t.Fatalf("failed test\n%s: %v", test.path, place)
+			}
+		}
+	}
+}
+
+func TestJSONLocator_encode(t *testing.T) {
+
+	content := `{"version":"1.0.0","build":1}`
+
+	{
+		places, err := (&jsonLocator{path: []pathSegment{{kind: keySegment, key: "version"}}}).locate(content)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		encoded, err := places[0].encode(`2.0.0 "beta" <a&b>`)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+		if encoded != `"2.0.0 \"beta\" <a&b>"` {
+			t.Fatal("failed test\n", encoded)
+		}
+	}
+	{
+		places, err := (&jsonLocator{path: []pathSegment{{kind: keySegment, key: "build"}}}).locate(content)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		encoded, err := places[0].encode("2")
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+		if encoded != "2" {
+			t.Fatal("failed test\n", encoded)
+		}
+
+		_, err = places[0].encode("2.0.0")
+		if err == nil || err.Error() != "'2.0.0' is not a valid JSON value" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+}
+
+func TestJSONLocator_invalidJSON(t *testing.T) {
+
+	_, err := (&jsonLocator{}).locate("{\n  \"a\": 1,\n}")
+	if err == nil || err.Error() != "failed to parse as JSON in line 3: invalid character '}' looking for beginning of object key string" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
@@ -0,0 +1,286 @@
+package emv
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// place is a place in the content where a value is embedded.
+type place struct {
+	start int
+	end   int
+	// text is the current text at the place.
+	text string
+	// groups are available in the replacement as {{.match.name}}.
+	groups map[string]string
+	// encode converts the rendered replacement into the text to put at the place.
+	encode func(replacement string) (string, error)
+	// expand expands $1 and ${name} in the text with the groups of the match. It is nil for the kinds other than regex.
+	expand func(text string) string
+}
+
+// locator finds the places of an embedded in the content.
+// The places must be in order and must not overlap.
+type locator interface {
+	locate(content string) ([]place, error)
+}
+
+func newLocator(embedded Embedded) (locator, error) {
+
+	switch embedded.Kind {
+	case "", "regex":
+		regex, err := regexp.Compile(embedded.Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "'%s' in embeddeds-pattern is an invalid value", embedded.Pattern)
+		}
+
+		return &regexLocator{regex: regex}, nil
+	case "block":
+		if embedded.Begin == "" || embedded.End == "" {
+			return nil, errors.Errorf("embeddeds-begin and embeddeds-end are required for the block kind")
+		}
+
+		return &blockLocator{
+			begin: embedded.Begin,
+			end:   embedded.End,
+		}, nil
+	case "json":
+		path, err := parseLocatorPath(embedded)
+		if err != nil {
+			return nil, err
+		}
+
+		return &jsonLocator{path: path}, nil
+	case "yaml":
+		path, err := parseLocatorPath(embedded)
+		if err != nil {
+			return nil, err
+		}
+
+		return &yamlLocator{path: path}, nil
+	case "toml":
+		path, err := parseLocatorPath(embedded)
+		if err != nil {
+			return nil, err
+		}
+
+		return &tomlLocator{path: path}, nil
+	case "properties", "ini", "dotenv":
+		if embedded.Key == "" {
+			return nil, errors.Errorf("embeddeds-key is required for the %s kind", embedded.Kind)
+		}
+
+		switch embedded.Kind {
+		case "properties":
+			return &propertiesLocator{key: embedded.Key}, nil
+		case "ini":
+			return &iniLocator{section: embedded.Section, key: embedded.Key}, nil
+		default:
+			return &dotenvLocator{key: embedded.Key}, nil
+		}
+	case "xml":
+		if embedded.Path == "" {
+			return nil, errors.Errorf("embeddeds-path is required for the %s kind", embedded.Kind)
+		}
+
+		steps, err := parseXPath(embedded.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "'%s' in embeddeds-path is an invalid value", embedded.Path)
+		}
+
+		return &xmlLocator{steps: steps}, nil
+	default:
+		return nil, errors.Errorf("'%s' in embeddeds-kind is an invalid value", embedded.Kind)
+	}
+}
+
+// locationField returns the field of Embedded that identifies the places of the kind.
+func locationField(kind string) string {
+
+	switch kind {
+	case "block":
+		return "begin"
+	case "json", "yaml", "xml", "toml":
+		return "path"
+	case "properties", "ini", "dotenv":
+		return "key"
+	default:
+		return "pattern"
+	}
+}
+
+// location returns the value of the field that identifies the places.
+func (e Embedded) location() string {
+
+	switch locationField(e.Kind) {
+	case "begin":
+		return e.Begin
+	case "path":
+		return e.Path
+	case "key":
+		return e.Key
+	default:
+		return e.Pattern
+	}
+}
+
+// parseLocatorPath parses the path of the kinds that find the values by the path in the structure.
+func parseLocatorPath(embedded Embedded) ([]pathSegment, error) {
+
+	if embedded.Path == "" {
+		return nil, errors.Errorf("embeddeds-path is required for the %s kind", embedded.Kind)
+	}
+
+	path, err := parsePath(embedded.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "'%s' in embeddeds-path is an invalid value", embedded.Path)
+	}
+
+	return path, nil
+}
+
+// regexLocator finds the matches of the regular expression.
+type regexLocator struct {
+	regex *regexp.Regexp
+}
+
+func (l *regexLocator) locate(content string) ([]place, error) {
+
+	places := []place{}
+
+	for _, loc := range l.regex.FindAllStringSubmatchIndex(content, -1) {
+
+		loc := loc
+		groups := map[string]string{}
+		for i, name := range l.regex.SubexpNames() {
+			if i != 0 && name != "" && loc[2*i] >= 0 {
+				groups[name] = content[loc[2*i]:loc[2*i+1]]
+			}
+		}
+
+		places = append(places, place{
+			start:  loc[0],
+			end:    loc[1],
+			text:   content[loc[0]:loc[1]],
+			groups: groups,
+			encode: func(replacement string) (string, error) {
+				return replacement, nil
+			},
+			expand: func(text string) string {
+				// $1 and ${name} are expanded in the same way as ReplaceAllString.
+				return string(l.regex.ExpandString(nil, text, content, loc))
+			},
+		})
+	}
+
+	return places, nil
+}
+
+// blockLocator finds the text between the begin and the end markers.
+// When the markers are on their own lines, the lines between them are the place,
+// and a newline is added to the end of the replacement if it is missing.
+type blockLocator struct {
+	begin string
+	end   string
+}
+
+func (l *blockLocator) locate(content string) ([]place, error) {
+
+	places := []place{}
+	offset := 0
+
+	for {
+		index := strings.Index(content[offset:], l.begin)
+		if index == -1 {
+			break
+		}
+
+		start := offset + index + len(l.begin)
+		index = strings.Index(content[start:], l.end)
+		if index == -1 {
+			return nil, errors.Errorf("'%s' in embeddeds-end is not found after '%s' in line %d", l.end, l.begin, lineNumber(content, start))
+		}
+
+		end := start + index
+		offset = end + len(l.end)
+
+		newline := ""
+		if strings.HasPrefix(content[start:end], "\r\n") {
+			newline = "\r\n"
+		} else if strings.HasPrefix(content[start:end], "\n") {
+			newline = "\n"
+		}
+
+		lineStart := strings.LastIndex(content[:end], "\n") + 1
+		if newline == "" || lineStart < start+len(newline) || strings.TrimSpace(content[lineStart:end]) != "" {
+			// The markers are in a line.
+			places = append(places, place{
+				start:  start,
+				end:    end,
+				text:   content[start:end],
+				encode: func(replacement string) (string, error) { return replacement, nil },
+			})
+			continue
+		}
+
+		start += len(newline)
+		end = lineStart
+
+		places = append(places, place{
+			start: start,
+			end:   end,
+			text:  strings.TrimSuffix(content[start:end], newline),
+			encode: func(replacement string) (string, error) {
+				if replacement != "" && !strings.HasSuffix(replacement, "\n") {
+					replacement += newline
+				}
+				return replacement, nil
+			},
+		})
+	}
+
+	return places, nil
+}
+
+// lineNumber returns the line number (1-based) of the offset.
+func lineNumber(content string, offset int) int {
+	return strings.Count(content[:offset], "\n") + 1
+}
+
+// lineEnd returns the end of the line from the offset without the newline, and the start of the next line.
+func lineEnd(content string, offset int) (int, int) {
+
+	index := strings.IndexByte(content[offset:], '\n')
+	if index == -1 {
+		return len(content), len(content)
+	}
+
+	end := offset + index
+	if end > offset && content[end-1] == '\r' {
+		return end - 1, end + 1
+	}
+
+	return end, end + 1
+}
+
+// skipSpaces returns the offset after the spaces and the tabs.
+func skipSpaces(content string, offset int, end int) int {
+
+	for offset < end && (content[offset] == ' ' || content[offset] == '\t' || content[offset] == '\f') {
+		offset++
+	}
+
+	return offset
+}
+
+// trimSpacesEnd returns the end before the trailing spaces and tabs.
+func trimSpacesEnd(content string, start int, end int) int {
+
+	for end > start && (content[end-1] == ' ' || content[end-1] == '\t' || content[end-1] == '\f') {
+		end--
+	}
+
+	return end
+}
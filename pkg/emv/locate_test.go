@@ -0,0 +1,116 @@
+package emv
+
+import (
+	"testing"
+)
+
+func TestBlockLocator(t *testing.T) {
+
+	content := "# Install\n<!-- emv:begin -->\n```\nnpm install x@1.0.0\n```\n  <!-- emv:end -->\n\nv<!-- emv:begin -->1.0.0<!-- emv:end -->"
+
+	locator := &blockLocator{begin: "<!-- emv:begin -->", end: "<!-- emv:end -->"}
+
+	places, err := locator.locate(content)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if len(places) != 2 {
+		t.Fatal("failed test\n", places)
+	}
+
+	// lines between the markers
+	if content[places[0].start:places[0].end] != "```\nnpm install x@1.0.0\n```\n" ||
+		places[0].text != "```\nnpm install x@1.0.0\n```" {
+		t.Fatal("failed test\n", places[0])
+	}
+
+	encoded, err := places[0].encode("```\nnpm install x@2.0.0\n```")
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+	if encoded != "```\nnpm install x@2.0.0\n```\n" {
+		t.Fatal("failed test\n", encoded)
+	}
+
+	// markers in a line
+	if content[places[1].start:places[1].end] != "1.0.0" || places[1].text != "1.0.0" {
+		t.Fatal("failed test\n", places[1])
+	}
+
+	encoded, err = places[1].encode("2.0.0")
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+	if encoded != "2.0.0" {
+		t.Fatal("failed test\n", encoded)
+	}
+}
+
+func TestBlockLocator_crlf(t *testing.T) {
+
+	content := "<!-- begin -->\r\nold\r\n<!-- end -->\r\n"
+
+	locator := &blockLocator{begin: "<!-- begin -->", end: "<!-- end -->"}
+
+	places, err := locator.locate(content)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if len(places) != 1 || places[0].text != "old" {
+		t.Fatal("failed test\n", places)
+	}
+
+	encoded, err := places[0].encode("new1\r\nnew2")
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+	if encoded != "new1\r\nnew2\r\n" {
+		t.Fatal("failed test\n", encoded)
+	}
+}
+
+func TestBlockLocator_endNotFound(t *testing.T) {
+
+	locator := &blockLocator{begin: "<!-- begin -->", end: "<!-- end -->"}
+
+	_, err := locator.locate("a\n<!-- begin -->\nb\n<!-- begin -->\nc\n<!-- end -->\n<!-- begin -->\n")
+	if err == nil || err.Error() != "'<!-- end -->' in embeddeds-end is not found after '<!-- begin -->' in line 7" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestNewLocator_invalid(t *testing.T) {
+
+	{
+		_, err := newLocator(Embedded{Kind: "block", Begin: "<!-- begin -->"})
+		if err == nil || err.Error() != "embeddeds-begin and embeddeds-end are required for the block kind" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		_, err := newLocator(Embedded{Kind: "json"})
+		if err == nil || err.Error() != "embeddeds-path is required for the json kind" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		_, err := newLocator(Embedded{Kind: "json", Path: "a..b"})
+		if err == nil || err.Error() != "'a..b' in embeddeds-path is an invalid value: a key is empty in 'a..b'" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		_, err := newLocator(Embedded{Kind: "ini", Section: "app"})
+		if err == nil || err.Error() != "embeddeds-key is required for the ini kind" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		_, err := newLocator(Embedded{Kind: "csv"})
+		if err == nil || err.Error() != "'csv' in embeddeds-kind is an invalid value" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package emv
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/onozaty/emv/internal/strutil"
+	"github.com/pkg/errors"
+)
+
+type pathSegmentKind int
+
+const (
+	// keySegment is a key of a mapping. (e.g. image in image.tag)
+	keySegment pathSegmentKind = iota
+	// indexSegment is an index of an array. (e.g. [0])
+	indexSegment
+	// selectSegment is the elements of an array whose field has the value. (e.g. [name=app])
+	selectSegment
+)
+
+type pathSegment struct {
+	kind pathSegmentKind
+	// key is the key of the mapping, or the field of the elements for selectSegment.
+	key   string
+	index int
+	// value is the value of the field for selectSegment.
+	value string
+}
+
+// matchIndex reports whether the segment refers to the element of the index in an array.
+// A key of a JSON Pointer such as /items/0 is also an index.
+func (s pathSegment) matchIndex(index int) bool {
+
+	switch s.kind {
+	case indexSegment:
+		return s.index == index
+	case keySegment:
+		return s.key == strconv.Itoa(index)
+	default:
+		return false
+	}
+}
+
+// parsePath parses a JSON Pointer (e.g. /dependencies/mylib) or a dotted key path
+// (e.g. $.dependencies.mylib, image.tag, containers[name=app].image, items[0], ['key.with.dots']).
+func parsePath(path string) ([]pathSegment, error) {
+
+	if path == "" {
+		return nil, errors.New("path is empty")
+	}
+
+	if strings.HasPrefix(path, "/") {
+		return parsePointer(path), nil
+	}
+
+	return parseDottedPath(path)
+}
+
+// parsePointer parses a JSON Pointer. (RFC 6901)
+func parsePointer(pointer string) []pathSegment {
+
+	segments := []pathSegment{}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		key := strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		segments = append(segments, pathSegment{kind: keySegment, key: key})
+	}
+
+	return segments
+}
+
+func parseDottedPath(path string) ([]pathSegment, error) {
+
+	rest := strings.TrimPrefix(path, "$")
+	segments := []pathSegment{}
+
+	for first := true; rest != ""; first = false {
+
+		switch {
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, errors.Errorf("']' is missing in '%s'", path)
+			}
+			// A quoted key may contain ']'.
+			if len(rest) > 1 && (rest[1] == '\'' || rest[1] == '"') {
+				quoteEnd := strings.IndexByte(rest[2:], rest[1])
+				if quoteEnd == -1 || !strings.HasPrefix(rest[2+quoteEnd+1:], "]") {
+					return nil, errors.Errorf("the quote is not closed in '%s'", path)
+				}
+				end = 2 + quoteEnd + 1
+			}
+
+			segment, err := parseBracket(rest[1:end])
+			if err != nil {
+				return nil, errors.Wrapf(err, "'%s' is an invalid path", path)
+			}
+
+			segments = append(segments, segment)
+			rest = rest[end+1:]
+		case rest[0] == '.' || first:
+			if rest[0] == '.' {
+				rest = rest[1:]
+			}
+
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, errors.Errorf("a key is empty in '%s'", path)
+			}
+
+			segments = append(segments, pathSegment{kind: keySegment, key: rest[:end]})
+			rest = rest[end:]
+		default:
+			return nil, errors.Errorf("'.' or '[' is expected after ']' in '%s'", path)
+		}
+	}
+
+	return segments, nil
+}
+
+// parseBracket parses the inside of [], an index, a quoted key or a selector.
+func parseBracket(text string) (pathSegment, error) {
+
+	if key, ok := unquotePathText(text); ok {
+		return pathSegment{kind: keySegment, key: key}, nil
+	}
+
+	if index, err := strconv.Atoi(text); err == nil {
+		if index < 0 {
+			return pathSegment{}, errors.Errorf("'%d' is a negative index", index)
+		}
+		return pathSegment{kind: indexSegment, index: index}, nil
+	}
+
+	key, value, ok := strutil.Cut(text, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return pathSegment{}, errors.Errorf("'[%s]' must be an index, a quoted key or a selector such as [name=value]", text)
+	}
+
+	value = strings.TrimSpace(value)
+	if unquoted, ok := unquotePathText(value); ok {
+		value = unquoted
+	}
+
+	return pathSegment{kind: selectSegment, key: key, value: value}, nil
+}
+
+func unquotePathText(text string) (string, bool) {
+
+	if len(text) >= 2 && (text[0] == '\'' || text[0] == '"') && text[len(text)-1] == text[0] {
+		return text[1 : len(text)-1], true
+	}
+
+	return "", false
+}
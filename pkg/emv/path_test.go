@@ -0,0 +1,58 @@
+package emv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+
+	tests := []struct {
+		path   string
+		expect []pathSegment
+	}{
+		{"/version", []pathSegment{{kind: keySegment, key: "version"}}},
+		{"/a~1b/c~0d/0", []pathSegment{{kind: keySegment, key: "a/b"}, {kind: keySegment, key: "c~d"}, {kind: keySegment, key: "0"}}},
+		{"$.dependencies.mylib", []pathSegment{{kind: keySegment, key: "dependencies"}, {kind: keySegment, key: "mylib"}}},
+		{"image.tag", []pathSegment{{kind: keySegment, key: "image"}, {kind: keySegment, key: "tag"}}},
+		{"$", []pathSegment{}},
+		{"items[1].name", []pathSegment{{kind: keySegment, key: "items"}, {kind: indexSegment, index: 1}, {kind: keySegment, key: "name"}}},
+		{"$['a.b'][\"c]\"]", []pathSegment{{kind: keySegment, key: "a.b"}, {kind: keySegment, key: "c]"}}},
+		{"containers[name=app].image", []pathSegment{{kind: keySegment, key: "containers"}, {kind: selectSegment, key: "name", value: "app"}, {kind: keySegment, key: "image"}}},
+		{"containers[ name = 'my app' ]", []pathSegment{{kind: keySegment, key: "containers"}, {kind: selectSegment, key: "name", value: "my app"}}},
+	}
+
+	for _, test := range tests {
+		result, err := parsePath(test.path)
+		if err != nil {
+			t.Fatalf("failed test\n%s\n%+v", test.path, err)
+		}
+
+		if !reflect.DeepEqual(result, test.expect) {
+			t.Fatalf("failed test\n%s: %+v", test.path, result)
+		}
+	}
+}
+
+func TestParsePath_invalid(t *testing.T) {
+
+	tests := []struct {
+		path   string
+		expect string
+	}{
+		{"", "path is empty"},
+		{"a..b", "a key is empty in 'a..b'"},
+		{"a[0", "']' is missing in 'a[0'"},
+		{"a['b]", "the quote is not closed in 'a['b]'"},
+		{"a[0]b", "'.' or '[' is expected after ']' in 'a[0]b'"},
+		{"a[-1]", "'a[-1]' is an invalid path: '-1' is a negative index"},
+		{"a[x]", "'a[x]' is an invalid path: '[x]' must be an index, a quoted key or a selector such as [name=value]"},
+	}
+
+	for _, test := range tests {
+		_, err := parsePath(test.path)
+		if err == nil || err.Error() != test.expect {
+			t.Fatalf("failed test\n%s\n%+v", test.path, err)
+		}
+	}
+}
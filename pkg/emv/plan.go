@@ -0,0 +1,221 @@
+package emv
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Plan is the contents of the target files computed with the values.
+type Plan struct {
+	Targets []TargetPlan
+	// Warnings are the problems of the target files that do not stop embedding,
+	// such as a pattern whose matches are all excluded.
+	Warnings []string
+	// writes are the files to be changed.
+	writes []FileWrite
+}
+
+type TargetPlan struct {
+	ReplaceRules []ReplaceRule
+	Files        []FilePlan
+	OnUnchanged  string
+}
+
+type FilePlan struct {
+	TargetFile
+	Before string
+	After  string
+	// MatchCounts are the number of matches for each rule.
+	MatchCounts []int
+}
+
+func (f FilePlan) Changed() bool {
+	return f.Before != f.After
+}
+
+// ChangedLines returns the line numbers changed in the content before embedding.
+func (f FilePlan) ChangedLines() []int {
+	return changedLines(f.Before, f.After)
+}
+
+// Diff returns the changes as a unified diff.
+func (f FilePlan) Diff() string {
+	return unifiedDiff(f.Name, f.Before, f.After)
+}
+
+type FileWrite struct {
+	Path     string
+	Original string
+	Content  string
+}
+
+// plan computes the contents of all target files without writing them.
+// When a file is included in multiple targets, the later target is applied to the result of the earlier one.
+func plan(fsys FS, config *Config, values map[string]string, targetDirPath string) (*Plan, error) {
+
+	targetPlans := []TargetPlan{}
+	warnings := []string{}
+
+	fileWrites := []FileWrite{}
+	fileWriteIndexes := map[string]int{}
+
+	for _, target := range config.Targets {
+
+		if !isValidOnUnchanged(target.OnUnchanged) {
+			return nil, errors.Errorf("'%s' in targets-onUnchanged is an invalid value", target.OnUnchanged)
+		}
+
+		replaceRules, err := buildReplaceRules(target.Embeddeds, config.Escape, values)
+		if err != nil {
+			return nil, err
+		}
+
+		files, fileWarnings, err := targetFiles(fsys, target.Files, target.Excludes, targetDirPath)
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, fileWarnings...)
+
+		filePlans := []FilePlan{}
+		for _, file := range files {
+
+			index, ok := fileWriteIndexes[file.Path]
+			if !ok {
+				content, err := fsys.ReadFile(file.Path)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+
+				index = len(fileWrites)
+				fileWriteIndexes[file.Path] = index
+				fileWrites = append(fileWrites, FileWrite{
+					Path:     file.Path,
+					Original: string(content),
+					Content:  string(content),
+				})
+			}
+
+			before := fileWrites[index].Content
+			after, matchCounts, err := replaceContent(before, replaceRules)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to embed in %s", file.Name)
+			}
+			fileWrites[index].Content = after
+
+			for i, matchCount := range matchCounts {
+				if err := replaceRules[i].Expect.check(matchCount); err != nil {
+					return nil, errors.Wrapf(err, "'%s' in embeddeds-%s for %s", replaceRules[i].Location, locationField(replaceRules[i].Kind), file.Name)
+				}
+			}
+
+			filePlans = append(filePlans, FilePlan{
+				TargetFile:  file,
+				Before:      before,
+				After:       after,
+				MatchCounts: matchCounts,
+			})
+		}
+
+		targetPlans = append(targetPlans, TargetPlan{
+			ReplaceRules: replaceRules,
+			Files:        filePlans,
+			OnUnchanged:  target.OnUnchanged,
+		})
+	}
+
+	changedFileWrites := []FileWrite{}
+	for _, fileWrite := range fileWrites {
+		if fileWrite.Original != fileWrite.Content {
+			changedFileWrites = append(changedFileWrites, fileWrite)
+		}
+	}
+
+	return &Plan{
+		Targets:  targetPlans,
+		Warnings: warnings,
+		writes:   changedFileWrites,
+	}, nil
+}
+
+func isValidOnUnchanged(onUnchanged string) bool {
+
+	switch onUnchanged {
+	case "", "ignore", "warn", "error":
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckUnchanged applies the onUnchanged policy of each target to the files that are not changed.
+// It returns the warnings, or an error if any file is not allowed to be unchanged.
+// If onUnchanged is not specified, it is "error" in strict mode and "ignore" otherwise.
+func (p *Plan) CheckUnchanged(strict bool) ([]string, error) {
+
+	warnings := []string{}
+	unchangedFiles := []string{}
+
+	for _, targetPlan := range p.Targets {
+
+		onUnchanged := targetPlan.OnUnchanged
+		if onUnchanged == "" {
+			if strict {
+				onUnchanged = "error"
+			} else {
+				onUnchanged = "ignore"
+			}
+		}
+
+		for _, file := range targetPlan.Files {
+			if file.Changed() {
+				continue
+			}
+
+			switch onUnchanged {
+			case "warn":
+				warnings = append(warnings, fmt.Sprintf("%s was not changed", file.Name))
+			case "error":
+				unchangedFiles = append(unchangedFiles, file.Name)
+			}
+		}
+	}
+
+	if len(unchangedFiles) != 0 {
+		return nil, errors.Errorf("%d file(s) were not changed: %s", len(unchangedFiles), strings.Join(unchangedFiles, ", "))
+	}
+
+	return warnings, nil
+}
+
+// writeFiles writes all files, or none of them.
+// If writing a file fails, the files already written are restored to the original contents.
+// Restoring is continued even if it fails for some files, and those files are reported with the error.
+func writeFiles(fsys FS, fileWrites []FileWrite) error {
+
+	for i, fileWrite := range fileWrites {
+
+		err := fsys.WriteFile(fileWrite.Path, []byte(fileWrite.Content))
+		if err == nil {
+			continue
+		}
+
+		err = errors.Wrapf(err, "failed to write %s", fileWrite.Path)
+
+		rollbackFailures := []string{}
+		for _, written := range fileWrites[:i] {
+			if rollbackErr := fsys.WriteFile(written.Path, []byte(written.Original)); rollbackErr != nil {
+				rollbackFailures = append(rollbackFailures, fmt.Sprintf("%s (%v)", written.Path, rollbackErr))
+			}
+		}
+
+		if len(rollbackFailures) != 0 {
+			return errors.Wrapf(err, "failed to restore %s", strings.Join(rollbackFailures, ", "))
+		}
+
+		return err
+	}
+
+	return nil
+}
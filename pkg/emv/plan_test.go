@@ -0,0 +1,198 @@
+package emv
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestPlan_sameFileInMultipleTargets(t *testing.T) {
+
+	fsys := MapFS{
+		filepath.Join("dir", "a.txt"): "version=1.0.0, date=2021-11-23",
+	}
+
+	config := &Config{
+		Targets: []Target{
+			{
+				Files: []string{"a.txt"},
+				Embeddeds: []Embedded{
+					{
+						Pattern:     "version=[0-9.]+",
+						Replacement: "version={{.version}}",
+					},
+				},
+			},
+			{
+				Files: []string{"a.txt"},
+				Embeddeds: []Embedded{
+					{
+						Pattern:     "date=[0-9-]+",
+						Replacement: "date={{.date}}",
+					},
+				},
+			},
+		},
+	}
+
+	values := map[string]string{
+		"version": "2.0.0",
+		"date":    "2021-12-24",
+	}
+
+	engine := &Engine{Config: config, BaseDir: "dir", FS: fsys}
+
+	plan, err := engine.Plan(values)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if plan.Targets[0].Files[0].Before != "version=1.0.0, date=2021-11-23" ||
+		plan.Targets[0].Files[0].After != "version=2.0.0, date=2021-11-23" {
+		t.Fatal("failed test\n", plan.Targets[0].Files[0])
+	}
+
+	if plan.Targets[1].Files[0].Before != "version=2.0.0, date=2021-11-23" ||
+		plan.Targets[1].Files[0].After != "version=2.0.0, date=2021-12-24" {
+		t.Fatal("failed test\n", plan.Targets[1].Files[0])
+	}
+
+	if len(plan.writes) != 1 ||
+		plan.writes[0].Original != "version=1.0.0, date=2021-11-23" ||
+		plan.writes[0].Content != "version=2.0.0, date=2021-12-24" {
+		t.Fatal("failed test\n", plan.writes)
+	}
+
+	// not written
+	if fsys[filepath.Join("dir", "a.txt")] != "version=1.0.0, date=2021-11-23" {
+		t.Fatal("failed test")
+	}
+
+	if err := engine.Apply(plan); err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if fsys[filepath.Join("dir", "a.txt")] != "version=2.0.0, date=2021-12-24" {
+		t.Fatal("failed test")
+	}
+}
+
+func TestWriteFiles_rollback(t *testing.T) {
+
+	fsys := failingFS{
+		MapFS: MapFS{"a.txt": "a", "b.txt": "b", "c.txt": "c"},
+		name:  "c.txt",
+	}
+
+	err := writeFiles(fsys, []FileWrite{
+		{Path: "a.txt", Original: "a", Content: "A"},
+		{Path: "b.txt", Original: "b", Content: "B"},
+		{Path: "c.txt", Original: "c", Content: "C"},
+	})
+	if err == nil || err.Error() != "failed to write c.txt: write failed" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// restored
+	if !reflect.DeepEqual(fsys.MapFS, MapFS{"a.txt": "a", "b.txt": "b", "c.txt": "c"}) {
+		t.Fatal("failed test\n", fsys.MapFS)
+	}
+}
+
+func TestWriteFiles_rollbackFailed(t *testing.T) {
+
+	fsys := restoreFailingFS{
+		MapFS: MapFS{"a.txt": "a", "b.txt": "b", "c.txt": "c", "d.txt": "d"},
+		// writing d.txt fails, and restoring a.txt and c.txt fails
+		failures: map[string]string{"d.txt": "D", "a.txt": "a", "c.txt": "c"},
+	}
+
+	err := writeFiles(fsys, []FileWrite{
+		{Path: "a.txt", Original: "a", Content: "A"},
+		{Path: "b.txt", Original: "b", Content: "B"},
+		{Path: "c.txt", Original: "c", Content: "C"},
+		{Path: "d.txt", Original: "d", Content: "D"},
+	})
+	if err == nil || err.Error() != "failed to restore a.txt (write failed), c.txt (write failed): failed to write d.txt: write failed" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// b.txt is restored even though restoring a.txt failed
+	if !reflect.DeepEqual(fsys.MapFS, MapFS{"a.txt": "A", "b.txt": "b", "c.txt": "C", "d.txt": "d"}) {
+		t.Fatal("failed test\n", fsys.MapFS)
+	}
+}
+
+func TestCheckUnchanged(t *testing.T) {
+
+	changed := FilePlan{TargetFile: TargetFile{Name: "changed"}, Before: "a", After: "b"}
+	unchanged1 := FilePlan{TargetFile: TargetFile{Name: "unchanged1"}, Before: "a", After: "a"}
+	unchanged2 := FilePlan{TargetFile: TargetFile{Name: "unchanged2"}, Before: "a", After: "a"}
+	unchanged3 := FilePlan{TargetFile: TargetFile{Name: "unchanged3"}, Before: "a", After: "a"}
+
+	plan := &Plan{
+		Targets: []TargetPlan{
+			{Files: []FilePlan{changed, unchanged1}},
+			{Files: []FilePlan{unchanged2}, OnUnchanged: "warn"},
+			{Files: []FilePlan{unchanged3}, OnUnchanged: "ignore"},
+		},
+	}
+
+	{
+		warnings, err := plan.CheckUnchanged(false)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		if !reflect.DeepEqual(warnings, []string{"unchanged2 was not changed"}) {
+			t.Fatal("failed test\n", warnings)
+		}
+	}
+	{
+		// strict
+		_, err := plan.CheckUnchanged(true)
+		if err == nil || err.Error() != "1 file(s) were not changed: unchanged1" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		plan.Targets[2].OnUnchanged = "error"
+
+		_, err := plan.CheckUnchanged(false)
+		if err == nil || err.Error() != "1 file(s) were not changed: unchanged3" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+}
+
+// failingFS fails to write the file of the name.
+type failingFS struct {
+	MapFS
+	name string
+}
+
+func (f failingFS) WriteFile(name string, data []byte) error {
+
+	if name == f.name {
+		return errors.New("write failed")
+	}
+
+	return f.MapFS.WriteFile(name, data)
+}
+
+// restoreFailingFS fails to write the data to the file in failures.
+type restoreFailingFS struct {
+	MapFS
+	failures map[string]string
+}
+
+func (f restoreFailingFS) WriteFile(name string, data []byte) error {
+
+	if failure, ok := f.failures[name]; ok && failure == string(data) {
+		return errors.New("write failed")
+	}
+
+	return f.MapFS.WriteFile(name, data)
+}
@@ -0,0 +1,149 @@
+package emv
+
+import (
+	"strconv"
+	"strings"
+)
+
+// propertiesLocator finds the values of the key in a Java properties file.
+// Commented-out lines are skipped, and the keys are compared after they are unescaped.
+type propertiesLocator struct {
+	key string
+}
+
+func (l *propertiesLocator) locate(content string) ([]place, error) {
+
+	places := []place{}
+
+	for offset := 0; offset < len(content); {
+
+		start := skipSpaces(content, offset, len(content))
+		end, next := lineEnd(content, start)
+
+		// The logical line continues while the line ends with an odd number of backslashes.
+		for !isCommentProperty(content, start, end) && endsWithEscape(content[start:end]) && next < len(content) {
+			end, next = lineEnd(content, next)
+		}
+		offset = next
+
+		if start == end || isCommentProperty(content, start, end) {
+			continue
+		}
+
+		keyEnd := start
+		for keyEnd < end && !strings.ContainsRune("=: \t\f", rune(content[keyEnd])) {
+			if content[keyEnd] == '\\' {
+				keyEnd++
+			}
+			keyEnd++
+		}
+		if keyEnd > end {
+			keyEnd = end
+		}
+
+		valueStart := skipSpaces(content, keyEnd, end)
+		if valueStart < end && (content[valueStart] == '=' || content[valueStart] == ':') {
+			valueStart = skipSpaces(content, valueStart+1, end)
+		}
+
+		if unescapeProperties(content[start:keyEnd]) != l.key {
+			continue
+		}
+
+		places = append(places, place{
+			start:  valueStart,
+			end:    end,
+			text:   unescapeProperties(content[valueStart:end]),
+			encode: encodePropertiesValue,
+		})
+	}
+
+	return places, nil
+}
+
+func isCommentProperty(content string, start int, end int) bool {
+	return start < end && (content[start] == '#' || content[start] == '!')
+}
+
+func endsWithEscape(line string) bool {
+	return (len(line)-len(strings.TrimRight(line, `\`)))%2 == 1
+}
+
+// unescapeProperties reads the escape sequences and the line continuations of the properties.
+func unescapeProperties(text string) string {
+
+	b := &strings.Builder{}
+	for i := 0; i < len(text); i++ {
+
+		if text[i] != '\\' {
+			b.WriteByte(text[i])
+			continue
+		}
+
+		i++
+		if i == len(text) {
+			break
+		}
+
+		switch text[i] {
+		case '\r', '\n':
+			// The newline and the spaces at the start of the next line are not a part of the value.
+			if text[i] == '\r' && i+1 < len(text) && text[i+1] == '\n' {
+				i++
+			}
+			i = skipSpaces(text, i+1, len(text)) - 1
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 < len(text) {
+				if code, err := strconv.ParseUint(text[i+1:i+5], 16, 16); err == nil {
+					b.WriteRune(rune(code))
+					i += 4
+					continue
+				}
+			}
+			b.WriteByte('u')
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+
+	return b.String()
+}
+
+// encodePropertiesValue escapes the replacement to be read as the value of the properties.
+func encodePropertiesValue(replacement string) (string, error) {
+
+	b := &strings.Builder{}
+	for i, r := range replacement {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\f':
+			b.WriteString(`\f`)
+		case ' ':
+			if i == 0 {
+				// The spaces at the start of the value are skipped unless they are escaped.
+				b.WriteString(`\ `)
+			} else {
+				b.WriteRune(r)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,70 @@
+package emv
+
+import (
+	"testing"
+)
+
+func TestPropertiesLocator(t *testing.T) {
+
+	content := "# version=0.0.0\n! version=0.0.0\napp.version=1.0.0\nversion = 1.0.0\n  version:1.0.0\nversion 1.0.0\nmy\\ version=1.0.0\nversion=1.0.\\\n    0\\u0041\\\\\nversion=\n"
+
+	places, err := (&propertiesLocator{key: "version"}).locate(content)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expects := []struct {
+		text  string
+		place string
+	}{
+		{"1.0.0", "1.0.0"},
+		{"1.0.0", "1.0.0"},
+		{"1.0.0", "1.0.0"},
+		{"1.0.0A\\", "1.0.\\\n    0\\u0041\\\\"},
+		{"", ""},
+	}
+
+	if len(places) != len(expects) {
+		t.Fatal("failed test\n", places)
+	}
+	for i, expect := range expects {
+		if places[i].text != expect.text || content[places[i].start:places[i].end] != expect.place {
+			t.Fatal("failed test\n", places[i])
+		}
+	}
+
+	// The escaped key
+	places, err = (&propertiesLocator{key: "my version"}).locate(content)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+	if len(places) != 1 || places[0].text != "1.0.0" {
+		t.Fatal("failed test\n", places)
+	}
+}
+
+func TestPropertiesLocator_crlf(t *testing.T) {
+
+	content := "a=1\r\nversion=1.\\\r\n  0\r\nb=2\r\n"
+
+	places, err := (&propertiesLocator{key: "version"}).locate(content)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if len(places) != 1 || places[0].text != "1.0" || content[places[0].start:places[0].end] != "1.\\\r\n  0" {
+		t.Fatal("failed test\n", places)
+	}
+}
+
+func TestEncodePropertiesValue(t *testing.T) {
+
+	encoded, err := encodePropertiesValue(" a b\\c\nd\té=")
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if encoded != `\ a b\\c\nd\té=` {
+		t.Fatal("failed test\n", encoded)
+	}
+}
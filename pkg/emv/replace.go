@@ -0,0 +1,269 @@
+package emv
+
+import (
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/pkg/errors"
+)
+
+type ReplaceRule struct {
+	// Kind is the kind of the embedded. It is empty for regex.
+	Kind string
+	// Location is the pattern, or the value that identifies the places for the other kinds.
+	Location string
+	// Replacement is rendered with the values. If the template refers to {{.match.name}}, the groups are empty in it.
+	Replacement string
+	// Literal is true if $ in the replacement is not expanded as the groups of the pattern.
+	Literal bool
+	Expect  *Expect
+	locator locator
+	// template is rendered for each place. If it is nil, Replacement is used for all places.
+	template *template.Template
+	// values are the values to render the template.
+	values map[string]string
+	// expandValues is true if $ in the values is also expanded. (literal: false)
+	expandValues bool
+}
+
+// replaceContent applies the rules in order, and returns the result and the number of matches for each rule.
+func replaceContent(content string, replaceRules []ReplaceRule) (string, []int, error) {
+
+	matchCounts := []int{}
+	for _, replaceRule := range replaceRules {
+
+		replaced, matchCount, err := replaceRule.replace(content)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "'%s' in embeddeds-%s", replaceRule.Location, locationField(replaceRule.Kind))
+		}
+
+		content = replaced
+		matchCounts = append(matchCounts, matchCount)
+	}
+
+	return content, matchCounts, nil
+}
+
+// replace renders the template for each place, with the named groups of the match as {{.match.name}}.
+func (r ReplaceRule) replace(content string) (string, int, error) {
+
+	places, err := r.locator.locate(content)
+	if err != nil {
+		return "", 0, err
+	}
+
+	result := &strings.Builder{}
+	last := 0
+
+	for _, place := range places {
+
+		expand := place.expand
+		if r.Literal {
+			expand = nil
+		}
+
+		replacement := r.Replacement
+		if r.template != nil {
+			rendered, err := r.render(place.groups, expand)
+			if err != nil {
+				return "", 0, err
+			}
+			replacement = rendered
+		} else if expand != nil {
+			replacement = expand(replacement)
+		}
+
+		text, err := place.encode(replacement)
+		if err != nil {
+			return "", 0, errors.Wrapf(err, "line %d", lineNumber(content, place.start))
+		}
+
+		result.WriteString(content[last:place.start])
+		result.WriteString(text)
+		last = place.end
+	}
+	result.WriteString(content[last:])
+
+	return result.String(), len(places), nil
+}
+
+// render renders the template for the place.
+// $1 and ${name} are expanded only in the text of the template, not in the values, so that the functions receive
+// the values and the groups as they are.
+func (r ReplaceRule) render(groups map[string]string, expand func(string) string) (string, error) {
+
+	if expand == nil {
+		return renderTemplate(r.template, templateData(r.template, r.values, groups))
+	}
+
+	templ, err := expandTemplateText(r.template, expand)
+	if err != nil {
+		return "", err
+	}
+
+	values := r.values
+	if r.expandValues {
+		values = map[string]string{}
+		for name, value := range r.values {
+			values[name] = expand(value)
+		}
+	}
+
+	return renderTemplate(templ, templateData(templ, values, groups))
+}
+
+func buildReplaceRules(embeddeds []Embedded, defaultEscape string, values map[string]string) ([]ReplaceRule, error) {
+
+	replaceRules := []ReplaceRule{}
+
+	for _, emembedded := range embeddeds {
+
+		locator, err := newLocator(emembedded)
+		if err != nil {
+			return nil, err
+		}
+
+		if emembedded.Expect != nil {
+			if err := emembedded.Expect.validate(); err != nil {
+				return nil, errors.Wrap(err, "embeddeds-expect is an invalid value")
+			}
+		}
+
+		escape := emembedded.Escape
+		if escape == "" {
+			escape = defaultEscape
+		}
+
+		escaper, ok := escapers[escape]
+		if !ok {
+			return nil, errors.Errorf("'%s' in escape is an invalid value", escape)
+		}
+
+		escapedValues := map[string]string{}
+		for name, value := range values {
+			escapedValues[name] = escaper(value)
+		}
+
+		templ, err := parseTemplate(emembedded.Replacement)
+		if err != nil {
+			return nil, errors.Wrapf(err, "'%s' in embeddeds-replacement is an invalid value", emembedded.Replacement)
+		}
+
+		replacement, err := renderTemplate(templ, templateData(templ, escapedValues, map[string]string{}))
+		if err != nil {
+			return nil, errors.Wrapf(err, "'%s' in embeddeds-replacement is an invalid value", emembedded.Replacement)
+		}
+
+		replaceRule := ReplaceRule{
+			Kind:         emembedded.Kind,
+			Location:     emembedded.location(),
+			Replacement:  replacement,
+			Literal:      emembedded.Literal != nil && *emembedded.Literal,
+			Expect:       emembedded.Expect,
+			locator:      locator,
+			template:     templ,
+			values:       escapedValues,
+			expandValues: emembedded.Literal != nil && !*emembedded.Literal,
+		}
+
+		replaceRules = append(replaceRules, replaceRule)
+	}
+
+	return replaceRules, nil
+}
+
+func executeTemplate(templStr string, values map[string]string) (string, error) {
+
+	templ, err := parseTemplate(templStr)
+	if err != nil {
+		return "", err
+	}
+
+	return renderTemplate(templ, values)
+}
+
+func newTemplate() *template.Template {
+	return template.New("template").Option("missingkey=zero").Funcs(templateFuncs)
+}
+
+func parseTemplate(templStr string) (*template.Template, error) {
+
+	templ, err := newTemplate().Parse(templStr)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return templ, nil
+}
+
+// expandTemplateText returns a copy of the template in which the text outside the actions is expanded.
+func expandTemplateText(templ *template.Template, expand func(string) string) (*template.Template, error) {
+
+	if templ.Tree == nil {
+		return templ, nil
+	}
+
+	tree := templ.Tree.Copy()
+	expandTextNodes(tree.Root, expand)
+
+	expanded, err := newTemplate().AddParseTree(templ.Name(), tree)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return expanded, nil
+}
+
+func expandTextNodes(node parse.Node, expand func(string) string) {
+
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, child := range node.Nodes {
+			expandTextNodes(child, expand)
+		}
+	case *parse.TextNode:
+		node.Text = []byte(expand(string(node.Text)))
+	case *parse.IfNode:
+		expandTextNodes(node.List, expand)
+		expandTextNodes(node.ElseList, expand)
+	case *parse.RangeNode:
+		expandTextNodes(node.List, expand)
+		expandTextNodes(node.ElseList, expand)
+	case *parse.WithNode:
+		expandTextNodes(node.List, expand)
+		expandTextNodes(node.ElseList, expand)
+	}
+}
+
+func renderTemplate(templ *template.Template, data interface{}) (string, error) {
+
+	w := &strings.Builder{}
+	if err := templ.Execute(w, data); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return w.String(), nil
+}
+
+// templateData is the data of the replacement template, the values and the groups of the match as "match".
+// The names in the template that are not defined are empty, because missingkey=zero renders nil as <no value>
+// for the values of interface{}.
+func templateData(templ *template.Template, values map[string]string, groups map[string]string) map[string]interface{} {
+
+	data := map[string]interface{}{}
+	if templ.Tree != nil {
+		for _, name := range templateFieldNames(templ.Tree.Root) {
+			data[name] = ""
+		}
+	}
+	for name, value := range values {
+		data[name] = value
+	}
+	data["match"] = groups
+
+	return data
+}
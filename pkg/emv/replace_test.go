@@ -0,0 +1,378 @@
+package emv
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestReplaceContent(t *testing.T) {
+
+	contents := "version: 1, date: 2021-12-14"
+
+	replaceRules := []ReplaceRule{
+		{
+			locator:     &regexLocator{regex: regexp.MustCompile(`version: ([0-9]+)`)},
+			Replacement: "version: 2",
+		},
+		{
+			locator:     &regexLocator{regex: regexp.MustCompile(`date: ([0-9\-]+)`)},
+			Replacement: "date: 2021-12-24",
+		},
+	}
+
+	result, matchCounts, err := replaceContent(contents, replaceRules)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if result != "version: 2, date: 2021-12-24" {
+		t.Fatal("failed test\n", result)
+	}
+
+	if !reflect.DeepEqual(matchCounts, []int{1, 1}) {
+		t.Fatal("failed test\n", matchCounts)
+	}
+}
+
+func TestReplaceContent_match(t *testing.T) {
+
+	contents := "  version: 1.0.0\n    version: 1.0.0\nversion: 1.0.0"
+
+	embeddeds := []Embedded{
+		{
+			Pattern:     `(?m)^(?P<indent>\s*)version: [0-9.]+$`,
+			Replacement: "{{.match.indent}}version: {{.version}} ($1{{.match.unknown}})",
+		},
+	}
+
+	replaceRules, err := buildReplaceRules(embeddeds, "", map[string]string{"version": "2.0.0"})
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	// The groups are empty in the replacement to show.
+	if replaceRules[0].Replacement != "version: 2.0.0 ($1)" {
+		t.Fatal("failed test\n", replaceRules[0].Replacement)
+	}
+
+	result, matchCounts, err := replaceContent(contents, replaceRules)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if result != "  version: 2.0.0 (  )\n    version: 2.0.0 (    )\nversion: 2.0.0 ()" {
+		t.Fatal("failed test\n", result)
+	}
+
+	if !reflect.DeepEqual(matchCounts, []int{3}) {
+		t.Fatal("failed test\n", matchCounts)
+	}
+}
+
+func TestReplaceContent_matchError(t *testing.T) {
+
+	embeddeds := []Embedded{
+		{
+			Pattern:     `version=(?P<version>.+)`,
+			Replacement: `{{if .match.version}}{{index .match.version 10}}{{end}}`,
+		},
+	}
+
+	replaceRules, err := buildReplaceRules(embeddeds, "", map[string]string{})
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	_, _, err = replaceContent("version=1", replaceRules)
+	// The message from text/template depends on the Go version.
+	if err == nil || !strings.HasPrefix(err.Error(), "'version=(?P<version>.+)' in embeddeds-pattern: template: template:1:23: executing") {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestReplaceContent_undefined(t *testing.T) {
+
+	embeddeds := []Embedded{
+		{
+			Pattern:     `x=.*`,
+			Replacement: "x={{.nope}}{{.nope | upper}}{{.match.nope}}",
+		},
+	}
+
+	replaceRules, err := buildReplaceRules(embeddeds, "", map[string]string{})
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if replaceRules[0].Replacement != "x=" {
+		t.Fatal("failed test\n", replaceRules[0].Replacement)
+	}
+
+	result, _, err := replaceContent("x=1", replaceRules)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if result != "x=" {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestReplaceContent_literal(t *testing.T) {
+
+	contents := "pw=old\nprice=old\nx=old\ny=old\nz=a$1"
+
+	literal := true
+	notLiteral := false
+
+	embeddeds := []Embedded{
+		{
+			Pattern:     `pw=(\w+)`,
+			Replacement: "pw={{.pw}}",
+		},
+		{
+			// $1 in the replacement is expanded, but $ in the values is not.
+			Pattern:     `price=(\w+)`,
+			Replacement: "price={{.price}} (was $1)",
+		},
+		{
+			Pattern:     `x=(\w+)`,
+			Replacement: "x=$1{{.pw}}",
+			Literal:     &literal,
+		},
+		{
+			// $ in the values is also expanded.
+			Pattern:     `y=(\w+)`,
+			Replacement: "y={{.price}}",
+			Literal:     &notLiteral,
+		},
+		{
+			Pattern:     `z=(?P<old>.+)`,
+			Replacement: "z={{.match.old}} $1",
+		},
+	}
+
+	values := map[string]string{
+		"pw":    "${VAR}",
+		"price": "$5",
+	}
+
+	replaceRules, err := buildReplaceRules(embeddeds, "", values)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if replaceRules[0].Replacement != "pw=${VAR}" || replaceRules[0].Literal ||
+		replaceRules[2].Replacement != "x=$1${VAR}" || !replaceRules[2].Literal {
+		t.Fatal("failed test\n", replaceRules)
+	}
+
+	result, _, err := replaceContent(contents, replaceRules)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if result != "pw=${VAR}\nprice=$5 (was old)\nx=$1${VAR}\ny=\nz=a$1 a$1" {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestReplaceContent_dollarWithFuncs(t *testing.T) {
+
+	contents := "a=old\nb=old\nc=old\nd=$9"
+
+	embeddeds := []Embedded{
+		{
+			Pattern:     `a=\S+`,
+			Replacement: "a={{.v | sha256}}",
+		},
+		{
+			Pattern:     `b=\S+`,
+			Replacement: "b={{len .v}}",
+		},
+		{
+			Pattern:     `c=(\S+)`,
+			Replacement: `c={{.v | trimPrefix "$"}} ($1)`,
+		},
+		{
+			// The functions also receive the groups as they are.
+			Pattern:     `d=(?P<old>\S+)`,
+			Replacement: `d={{.match.old | trimPrefix "$"}}`,
+		},
+	}
+
+	replaceRules, err := buildReplaceRules(embeddeds, "", map[string]string{"v": "$5"})
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	result, _, err := replaceContent(contents, replaceRules)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if result != "a=4879e9c95387ed71c784881c6e1039027bef7552cfb5bae503e59995d2686bf2\nb=2\nc=5 (old)\nd=9" {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestBuildReplaceRules(t *testing.T) {
+
+	embeddeds := []Embedded{
+		{
+			Pattern:     "val1=(.+)",
+			Replacement: "val1={{.val1}}",
+		},
+		{
+			Pattern:     "val2=(.+)",
+			Replacement: "val2={{.val2}}",
+		},
+	}
+
+	values := map[string]string{
+		"val1": "a",
+		"val2": "b",
+	}
+
+	result, err := buildReplaceRules(embeddeds, "", values)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []ReplaceRule{
+		{
+			Location:    "val1=(.+)",
+			Replacement: "val1=a",
+		},
+		{
+			Location:    "val2=(.+)",
+			Replacement: "val2=b",
+		},
+	}
+
+	assertReplaceRules(t, result, expect)
+}
+
+func TestBuildReplaceRules_escape(t *testing.T) {
+
+	embeddeds := []Embedded{
+		{
+			Pattern:     "val1=(.+)",
+			Replacement: "val1={{.val1}}",
+		},
+		{
+			Pattern:     "val2=(.+)",
+			Replacement: "val2={{.val1}}",
+			Escape:      "none",
+		},
+		{
+			Pattern:     "val3=(.+)",
+			Replacement: "val3={{.val1}}",
+			Escape:      "json",
+		},
+	}
+
+	values := map[string]string{
+		"val1": `a<b&"c"`,
+	}
+
+	result, err := buildReplaceRules(embeddeds, "xml", values)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []ReplaceRule{
+		{
+			Location:    "val1=(.+)",
+			Replacement: "val1=a&lt;b&amp;&#34;c&#34;",
+		},
+		{
+			Location:    "val2=(.+)",
+			Replacement: `val2=a<b&"c"`,
+		},
+		{
+			Location:    "val3=(.+)",
+			Replacement: `val3=a<b&\"c\"`,
+		},
+	}
+
+	assertReplaceRules(t, result, expect)
+}
+
+func TestBuildReplaceRules_invalidEscape(t *testing.T) {
+
+	embeddeds := []Embedded{
+		{
+			Pattern:     "val1=(.+)",
+			Replacement: "val1={{.val1}}",
+			Escape:      "csv",
+		},
+	}
+
+	values := map[string]string{
+		"val1": "a",
+	}
+
+	_, err := buildReplaceRules(embeddeds, "", values)
+	if err == nil || err.Error() != "'csv' in escape is an invalid value" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestExecuteTemplate(t *testing.T) {
+
+	values := map[string]string{
+		"val1": "a",
+		"val2": "b",
+	}
+
+	templStr := "val1={{.val1}}, val2={{.val2}}"
+
+	result, err := executeTemplate(templStr, values)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if result != "val1=a, val2=b" {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestExecuteTemplate_notEscaped(t *testing.T) {
+
+	values := map[string]string{
+		"version": "1.0.0+build&x",
+		"expr":    "a<b",
+	}
+
+	templStr := "version={{.version}}, expr={{.expr}}, none={{.none}}"
+
+	result, err := executeTemplate(templStr, values)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if result != "version=1.0.0+build&x, expr=a<b, none=" {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+// assertReplaceRules compares the exported fields, because the template cannot be compared.
+func assertReplaceRules(t *testing.T, result []ReplaceRule, expect []ReplaceRule) {
+
+	if len(result) != len(expect) {
+		t.Fatal("failed test\n", result)
+	}
+
+	for i := range expect {
+		if result[i].Kind != expect[i].Kind ||
+			result[i].Location != expect[i].Location ||
+			result[i].Replacement != expect[i].Replacement ||
+			result[i].Literal != expect[i].Literal ||
+			result[i].Expect != expect[i].Expect {
+			t.Fatal("failed test\n", result[i])
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package emv
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var semverRegexp = regexp.MustCompile(`^(v?)(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(?:-([0-9A-Za-z\-]+(?:\.[0-9A-Za-z\-]+)*))?(?:\+([0-9A-Za-z\-]+(?:\.[0-9A-Za-z\-]+)*))?$`)
+
+type Semver struct {
+	Prefix     string
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Build      string
+}
+
+func parseSemver(version string) (*Semver, error) {
+
+	match := semverRegexp.FindStringSubmatch(version)
+	if match == nil {
+		return nil, errors.Errorf("'%s' is not a semantic version", version)
+	}
+
+	// The numbers have already been checked with the regular expression.
+	major, _ := strconv.Atoi(match[2])
+	minor, _ := strconv.Atoi(match[3])
+	patch, _ := strconv.Atoi(match[4])
+
+	return &Semver{
+		Prefix:     match[1],
+		Major:      major,
+		Minor:      minor,
+		Patch:      patch,
+		Prerelease: match[5],
+		Build:      match[6],
+	}, nil
+}
+
+func (v Semver) String() string {
+
+	s := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+
+	return s
+}
+
+// bumpVersion returns the next version in the same way as "npm version".
+// (e.g. 1.2.3 -> major: 2.0.0, minor: 1.3.0, patch: 1.2.4, prerelease: 1.2.4-0)
+func bumpVersion(version string, part string) (string, error) {
+
+	v, err := parseSemver(version)
+	if err != nil {
+		return "", err
+	}
+
+	// The build metadata is not carried over.
+	v.Build = ""
+
+	switch part {
+	case "major":
+		// A prerelease of a major version (e.g. 2.0.0-rc.1) is bumped to its release.
+		if v.Prerelease == "" || v.Minor != 0 || v.Patch != 0 {
+			v.Major++
+		}
+		v.Minor = 0
+		v.Patch = 0
+		v.Prerelease = ""
+	case "minor":
+		if v.Prerelease == "" || v.Patch != 0 {
+			v.Minor++
+		}
+		v.Patch = 0
+		v.Prerelease = ""
+	case "patch":
+		if v.Prerelease == "" {
+			v.Patch++
+		}
+		v.Prerelease = ""
+	case "prerelease":
+		if v.Prerelease == "" {
+			v.Patch++
+			v.Prerelease = "0"
+		} else {
+			v.Prerelease = bumpPrerelease(v.Prerelease)
+		}
+	default:
+		return "", errors.Errorf("'%s' is an invalid part to bump, it must be one of major, minor, patch and prerelease", part)
+	}
+
+	return v.String(), nil
+}
+
+func bumpPrerelease(prerelease string) string {
+
+	identifiers := strings.Split(prerelease, ".")
+	last := identifiers[len(identifiers)-1]
+
+	if number, err := strconv.Atoi(last); err == nil {
+		identifiers[len(identifiers)-1] = strconv.Itoa(number + 1)
+		return strings.Join(identifiers, ".")
+	}
+
+	return prerelease + ".0"
+}
@@ -0,0 +1,81 @@
+package emv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSemver(t *testing.T) {
+
+	result, err := parseSemver("v1.20.3-rc.1+build.5")
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := &Semver{
+		Prefix:     "v",
+		Major:      1,
+		Minor:      20,
+		Patch:      3,
+		Prerelease: "rc.1",
+		Build:      "build.5",
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+
+	if result.String() != "v1.20.3-rc.1+build.5" {
+		t.Fatal("failed test\n", result.String())
+	}
+}
+
+func TestParseSemver_invalid(t *testing.T) {
+
+	_, err := parseSemver("1.2")
+	if err == nil || err.Error() != "'1.2' is not a semantic version" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestBumpVersion(t *testing.T) {
+
+	tests := []struct {
+		version string
+		part    string
+		expect  string
+	}{
+		{"1.2.3", "major", "2.0.0"},
+		{"1.2.3", "minor", "1.3.0"},
+		{"1.2.3", "patch", "1.2.4"},
+		{"1.2.3", "prerelease", "1.2.4-0"},
+		{"v1.2.3+build.1", "patch", "v1.2.4"},
+		{"2.0.0-rc.1", "major", "2.0.0"},
+		{"2.1.0-rc.1", "major", "3.0.0"},
+		{"1.3.0-rc.1", "minor", "1.3.0"},
+		{"1.3.1-rc.1", "minor", "1.4.0"},
+		{"1.2.4-rc.1", "patch", "1.2.4"},
+		{"1.2.4-rc.1", "prerelease", "1.2.4-rc.2"},
+		{"1.2.4-rc", "prerelease", "1.2.4-rc.0"},
+		{"1.2.4-0", "prerelease", "1.2.4-1"},
+	}
+
+	for _, test := range tests {
+		result, err := bumpVersion(test.version, test.part)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		if result != test.expect {
+			t.Fatalf("failed test\n%s %s: %s", test.version, test.part, result)
+		}
+	}
+}
+
+func TestBumpVersion_invalidPart(t *testing.T) {
+
+	_, err := bumpVersion("1.2.3", "build")
+	if err == nil || err.Error() != "'build' is an invalid part to bump, it must be one of major, minor, patch and prerelease" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
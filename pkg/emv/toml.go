@@ -0,0 +1,266 @@
+package emv
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"github.com/pkg/errors"
+)
+
+// tomlLocator finds the values of the dotted key in a TOML document.
+// Only the text of the values is replaced, so that the comments and the formatting are kept.
+type tomlLocator struct {
+	path []pathSegment
+}
+
+// tomlKey is a key of the path to a value. index is the index in an array, or -1.
+type tomlKey struct {
+	key   string
+	index int
+}
+
+// tomlEntry is a value with the full path to it, such as package.version or bin[0].name.
+type tomlEntry struct {
+	keys  []tomlKey
+	kind  unstable.Kind
+	start int
+	end   int
+	// text is the decoded string, or the text of the other values.
+	text string
+	// quote is the delimiter of the string. (", ', """ or ''')
+	quote string
+	// newline is the newline after the opening delimiter of the multi-line string.
+	newline string
+}
+
+func (l *tomlLocator) locate(content string) ([]place, error) {
+
+	entries, err := parseTOMLEntries(content)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]string{}
+	for _, entry := range entries {
+		fields[tomlKeysString(entry.keys)] = entry.text
+	}
+
+	places := []place{}
+	for _, entry := range entries {
+
+		if !matchTOMLPath(l.path, entry.keys, fields) {
+			continue
+		}
+
+		if entry.kind == unstable.Array || entry.kind == unstable.InlineTable {
+			return nil, errors.Errorf("the value in line %d is not a scalar", lineNumber(content, entry.start))
+		}
+
+		places = append(places, place{
+			start:  entry.start,
+			end:    entry.end,
+			text:   entry.text,
+			encode: entry.encode,
+		})
+	}
+
+	sort.Slice(places, func(i, j int) bool { return places[i].start < places[j].start })
+
+	return places, nil
+}
+
+// matchTOMLPath reports whether the path refers to the keys.
+// [name=value] is compared with the fields of the elements.
+func matchTOMLPath(path []pathSegment, keys []tomlKey, fields map[string]string) bool {
+
+	i := 0
+	for k, key := range keys {
+
+		if i == len(path) || path[i].kind != keySegment || path[i].key != key.key {
+			return false
+		}
+		i++
+
+		if key.index == -1 {
+			continue
+		}
+
+		if i == len(path) {
+			return false
+		}
+
+		segment := path[i]
+		if segment.kind == selectSegment {
+			field := tomlKeysString(append(append([]tomlKey{}, keys[:k+1]...), tomlKey{key: segment.key, index: -1}))
+			if value, ok := fields[field]; !ok || value != segment.value {
+				return false
+			}
+		} else if !segment.matchIndex(key.index) {
+			return false
+		}
+		i++
+	}
+
+	return i == len(path)
+}
+
+func tomlKeysString(keys []tomlKey) string {
+
+	texts := []string{}
+	for _, key := range keys {
+		text := strconv.Quote(key.key)
+		if key.index != -1 {
+			text += "[" + strconv.Itoa(key.index) + "]"
+		}
+		texts = append(texts, text)
+	}
+
+	return strings.Join(texts, ".")
+}
+
+// parseTOMLEntries returns the values with the full paths to them, in the tables and the arrays of tables.
+func parseTOMLEntries(content string) ([]tomlEntry, error) {
+
+	parser := &unstable.Parser{}
+	parser.Reset([]byte(content))
+
+	entries := []tomlEntry{}
+	table := []tomlKey{}
+	// The number of the elements of each array of tables, and the index of the last one.
+	arrayCounts := map[string]int{}
+
+	for parser.NextExpression() {
+
+		expression := parser.Expression()
+
+		switch expression.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			table = []tomlKey{}
+			for it := expression.Key(); it.Next(); {
+				table = append(table, tomlKey{key: string(it.Node().Data), index: -1})
+
+				// The tables in an array of tables belong to the last element.
+				last := len(table) - 1
+				if count, ok := arrayCounts[tomlKeysString(table)]; ok && !(it.IsLast() && expression.Kind == unstable.ArrayTable) {
+					table[last].index = count - 1
+				}
+			}
+
+			if expression.Kind == unstable.ArrayTable {
+				name := tomlKeysString(table)
+				table[len(table)-1].index = arrayCounts[name]
+				arrayCounts[name]++
+			}
+		case unstable.KeyValue:
+			entries = appendTOMLEntries(entries, parser, table, expression)
+		}
+	}
+
+	if err := parser.Error(); err != nil {
+		if parserErr, ok := err.(*unstable.ParserError); ok && len(parserErr.Highlight) != 0 {
+			offset := int(parser.Range(parserErr.Highlight).Offset)
+			return nil, errors.Wrapf(err, "failed to parse as TOML in line %d", lineNumber(content, offset))
+		}
+		return nil, errors.Wrap(err, "failed to parse as TOML")
+	}
+
+	return entries, nil
+}
+
+// appendTOMLEntries appends the value of the key-value, and the values in it if it is an array or an inline table.
+func appendTOMLEntries(entries []tomlEntry, parser *unstable.Parser, parent []tomlKey, keyValue *unstable.Node) []tomlEntry {
+
+	keys := append([]tomlKey{}, parent...)
+	keyOffset := 0
+	for it := keyValue.Key(); it.Next(); {
+		keys = append(keys, tomlKey{key: string(it.Node().Data), index: -1})
+		keyOffset = int(it.Node().Raw.Offset)
+	}
+
+	return appendTOMLValue(entries, parser, keys, keyValue.Value(), keyOffset)
+}
+
+// appendTOMLValue appends the value. The offset of the key is used as the position of an array or an inline table.
+func appendTOMLValue(entries []tomlEntry, parser *unstable.Parser, keys []tomlKey, value *unstable.Node, keyOffset int) []tomlEntry {
+
+	entry := tomlEntry{keys: keys, kind: value.Kind}
+
+	switch value.Kind {
+	case unstable.String:
+		raw := parser.Raw(value.Raw)
+		entry.start = int(value.Raw.Offset)
+		entry.end = entry.start + len(raw)
+		entry.text = string(value.Data)
+
+		entry.quote = string(raw[:1])
+		if len(raw) >= 6 && (strings.HasPrefix(string(raw), `"""`) || strings.HasPrefix(string(raw), `'''`)) {
+			entry.quote = string(raw[:3])
+			if strings.HasPrefix(string(raw[3:]), "\r\n") {
+				entry.newline = "\r\n"
+			} else if strings.HasPrefix(string(raw[3:]), "\n") {
+				entry.newline = "\n"
+			}
+		}
+	case unstable.Array, unstable.InlineTable:
+		entry.start = keyOffset
+		entry.end = keyOffset
+
+		for i, it := 0, value.Children(); it.Next(); i++ {
+			child := it.Node()
+			if value.Kind == unstable.InlineTable {
+				entries = appendTOMLEntries(entries, parser, keys, child)
+				continue
+			}
+
+			elementKeys := append([]tomlKey{}, keys...)
+			elementKeys[len(elementKeys)-1].index = i
+			// An array in an array is not referred to by the path.
+			if keys[len(keys)-1].index == -1 {
+				entries = appendTOMLValue(entries, parser, elementKeys, child, keyOffset)
+			}
+		}
+	default:
+		r := parser.Range(value.Data)
+		entry.start = int(r.Offset)
+		entry.end = entry.start + int(r.Length)
+		entry.text = string(value.Data)
+	}
+
+	return append(entries, entry)
+}
+
+// encode writes the replacement as a string in the same quoting style, or as a value of the other kind.
+func (e tomlEntry) encode(replacement string) (string, error) {
+
+	if e.kind != unstable.String {
+		// The values other than strings are embedded as TOML. (e.g. 2, true, 2021-12-24)
+		if strings.ContainsAny(replacement, "\r\n") || toml.Unmarshal([]byte("value = "+replacement), &map[string]interface{}{}) != nil {
+			return "", errors.Errorf("'%s' is not a valid TOML value", replacement)
+		}
+		return replacement, nil
+	}
+
+	switch e.quote {
+	case `'`:
+		if !strings.ContainsAny(replacement, "'\r\n") {
+			return "'" + replacement + "'", nil
+		}
+	case `'''`:
+		if !strings.Contains(replacement, "'''") && !strings.HasSuffix(replacement, "'") {
+			return "'''" + e.newline + replacement + "'''", nil
+		}
+	case `"""`:
+		escaped := strings.ReplaceAll(replacement, `\`, `\\`)
+		escaped = strings.ReplaceAll(escaped, `"""`, `""\"`)
+		if strings.HasSuffix(escaped, `"`) {
+			escaped = strings.TrimSuffix(escaped, `"`) + `\"`
+		}
+		return `"""` + e.newline + escaped + `"""`, nil
+	}
+
+	// TOML basic strings accept the JSON escape sequences.
+	return `"` + escapeJSON(replacement) + `"`, nil
+}
@@ -0,0 +1,139 @@
+package emv
+
+import (
+	"testing"
+)
+
+func TestTOMLLocator(t *testing.T) {
+
+	content := `# Cargo.toml
+[package]
+name = "app"
+version = "1.0.0" # the version
+
+[workspace.dependencies]
+foo = { version = '1.0.0', features = ["a"] }
+bar.version = "1.0.0"
+
+[[bin]]
+name = "a"
+path = """
+src/a.rs"""
+
+[[bin]]
+name = "b"
+tags = ["x", "y"]
+
+[bin.meta]
+build = 2
+
+["tool.x"]
+value = 1.5
+`
+
+	tests := []struct {
+		path   string
+		texts  []string
+		places []string
+	}{
+		{"package.version", []string{"1.0.0"}, []string{`"1.0.0"`}},
+		{"workspace.dependencies.foo.version", []string{"1.0.0"}, []string{"'1.0.0'"}},
+		{"workspace.dependencies.foo.features[0]", []string{"a"}, []string{`"a"`}},
+		{"workspace.dependencies.bar.version", []string{"1.0.0"}, []string{`"1.0.0"`}},
+		{"bin[0].path", []string{"src/a.rs"}, []string{"\"\"\"\nsrc/a.rs\"\"\""}},
+		{"bin[name=b].tags[1]", []string{"y"}, []string{`"y"`}},
+		{"bin[1].meta.build", []string{"2"}, []string{"2"}},
+		{"bin.name", []string{}, []string{}},
+		{"$['tool.x'].value", []string{"1.5"}, []string{"1.5"}},
+		{"package", []string{}, []string{}},
+	}
+
+	for _, test := range tests {
+
+		path, err := parsePath(test.path)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		places, err := (&tomlLocator{path: path}).locate(content)
+		if err != nil {
+			t.Fatalf("failed test\n%s\n%+v", test.path, err)
+		}
+
+		if len(places) != len(test.texts) {
+			t.Fatalf("failed test\n%s: %v", test.path, places)
+		}
+		for i, place := range places {
+			if place.text != test.texts[i] || content[place.start:place.end] != test.places[i] {
+				t.Fatalf("failed test\n%s: %v", test.path, place)
+			}
+		}
+	}
+}
+
+func TestTOMLLocator_encode(t *testing.T) {
+
+	content := "basic = \"a\"\nliteral = 'a'\nmulti = \"\"\"\na\"\"\"\nmultiLiteral = '''a'''\nnumber = 1\n"
+
+	tests := []struct {
+		path        string
+		replacement string
+		expect      string
+	}{
+		{"basic", `a"b\c`, `"a\"b\\c"`},
+		{"literal", `a\b`, `'a\b'`},
+		{"literal", "it's", `"it's"`},
+		{"multi", "x\n\"\"\"y\\", "\"\"\"\nx\n\"\"\\\"y\\\\\"\"\""},
+		{"multiLiteral", "x\ny", "'''x\ny'''"},
+		{"multiLiteral", "x'''", `"x'''"`},
+		{"number", "2", "2"},
+	}
+
+	for _, test := range tests {
+
+		path, err := parsePath(test.path)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		places, err := (&tomlLocator{path: path}).locate(content)
+		if err != nil {
+			t.Fatalf("failed test\n%s\n%+v", test.path, err)
+		}
+
+		encoded, err := places[0].encode(test.replacement)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+		if encoded != test.expect {
+			t.Fatalf("failed test\n%s: %s", test.path, encoded)
+		}
+	}
+}
+
+func TestTOMLLocator_error(t *testing.T) {
+
+	{
+		places, err := (&tomlLocator{path: []pathSegment{{kind: keySegment, key: "number"}}}).locate("number = 1\n")
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		_, err = places[0].encode("1.0.0")
+		if err == nil || err.Error() != "'1.0.0' is not a valid TOML value" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		_, err := (&tomlLocator{path: []pathSegment{{kind: keySegment, key: "a"}}}).locate("b = 1\na = [1, 2]\n")
+		if err == nil || err.Error() != "the value in line 2 is not a scalar" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		_, err := (&tomlLocator{}).locate("a = 1\nb = \n")
+		if err == nil || err.Error() != "failed to parse as TOML in line 2: incomplete number" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+}
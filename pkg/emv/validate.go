@@ -0,0 +1,620 @@
+package emv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type ValidationError struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) String() string {
+
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Path, e.Message)
+	}
+
+	return fmt.Sprintf("%s (%d:%d): %s", e.Path, e.Line, e.Column, e.Message)
+}
+
+type configNodeKind int
+
+const (
+	scalarNode configNodeKind = iota
+	objectNode
+	arrayNode
+)
+
+// configNode is a config parsed with the positions, independent of the format.
+type configNode struct {
+	Kind   configNodeKind
+	Value  interface{}
+	Fields []configField
+	Items  []*configNode
+	Line   int
+	Column int
+}
+
+type configField struct {
+	Key    string
+	Line   int
+	Column int
+	Value  *configNode
+}
+
+// ValidateConfig checks the config and returns all errors found.
+// An error is returned only if the config cannot be parsed.
+func ValidateConfig(path string, content []byte) ([]ValidationError, error) {
+
+	root, err := parseConfigNode(path, content)
+	if err != nil {
+		return nil, err
+	}
+
+	// The errors are an empty array rather than null in JSON when the config is valid.
+	v := &configValidator{
+		errors: []ValidationError{},
+		nodes:  map[string]*configNode{},
+	}
+
+	v.validateNode("$", root, reflect.TypeOf(Config{}))
+
+	converted, err := configJSON(path, content)
+	if err != nil {
+		return nil, err
+	}
+
+	// If the types are wrong, it cannot be decoded, and the errors have already been reported.
+	var config Config
+	if err := json.Unmarshal(converted, &config); err == nil {
+		v.validateContents(&config)
+	}
+
+	return v.errors, nil
+}
+
+type configValidator struct {
+	errors []ValidationError
+	nodes  map[string]*configNode
+	// ignoreUnknown skips the unknown fields, which are ignored when the config is loaded.
+	ignoreUnknown bool
+}
+
+// typeErrors returns the errors of the types of the values in the config, in the same form as ValidateConfig.
+// It is used to explain why the config cannot be loaded.
+func typeErrors(path string, content []byte) []ValidationError {
+
+	root, err := parseConfigNode(path, content)
+	if err != nil {
+		return nil
+	}
+
+	v := &configValidator{
+		nodes:         map[string]*configNode{},
+		ignoreUnknown: true,
+	}
+
+	v.validateNode("$", root, reflect.TypeOf(Config{}))
+
+	return v.errors
+}
+
+func (v *configValidator) addError(path string, format string, args ...interface{}) {
+
+	validationError := ValidationError{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	}
+
+	if node, ok := v.nodes[path]; ok {
+		validationError.Line = node.Line
+		validationError.Column = node.Column
+	}
+
+	v.errors = append(v.errors, validationError)
+}
+
+// validateNode checks the structure of the node with the type, such as unknown fields and the types of the values.
+func (v *configValidator) validateNode(path string, node *configNode, typ reflect.Type) {
+
+	v.nodes[path] = node
+
+	if typ.Kind() == reflect.Ptr {
+		if node.Kind == scalarNode && node.Value == nil {
+			return
+		}
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		if node.Kind != objectNode {
+			v.addError(path, "must be an object")
+			return
+		}
+
+		for _, field := range node.Fields {
+			fieldPath := path + "." + field.Key
+
+			structField, ok := jsonField(typ, field.Key)
+			if !ok && v.ignoreUnknown {
+				continue
+			}
+			if !ok {
+				// The position of the key is more useful than the value for an unknown field.
+				v.nodes[fieldPath] = &configNode{Line: field.Line, Column: field.Column}
+				v.addError(fieldPath, "unknown field '%s'", field.Key)
+				continue
+			}
+
+			v.validateNode(fieldPath, field.Value, structField.Type)
+		}
+	case reflect.Slice:
+		if node.Kind != arrayNode {
+			v.addError(path, "must be an array")
+			return
+		}
+
+		for i, item := range node.Items {
+			v.validateNode(fmt.Sprintf("%s[%d]", path, i), item, typ.Elem())
+		}
+	case reflect.String:
+		if _, ok := node.Value.(string); node.Kind != scalarNode || !ok {
+			v.addError(path, "must be a string")
+		}
+	case reflect.Bool:
+		if _, ok := node.Value.(bool); node.Kind != scalarNode || !ok {
+			v.addError(path, "must be a boolean")
+		}
+	case reflect.Int:
+		if number, ok := node.Value.(json.Number); node.Kind != scalarNode || !ok || strings.ContainsAny(number.String(), ".eE") {
+			v.addError(path, "must be an integer")
+		}
+	}
+}
+
+func jsonField(typ reflect.Type, key string) (reflect.StructField, bool) {
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if strings.Split(field.Tag.Get("json"), ",")[0] == key {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// validateContents checks the values of the config, such as the regular expressions and the templates.
+func (v *configValidator) validateContents(config *Config) {
+
+	if len(config.Values) == 0 {
+		v.addError("$.values", "must not be empty")
+	}
+	if len(config.Targets) == 0 {
+		v.addError("$.targets", "must not be empty")
+	}
+
+	if _, ok := escapers[config.Escape]; !ok {
+		v.addError("$.escape", "'%s' is an invalid escape", config.Escape)
+	}
+
+	// The names available in the templates.
+	names := map[string]bool{}
+
+	for i, value := range config.Values {
+		path := fmt.Sprintf("$.values[%d]", i)
+
+		if value.Name == "" {
+			v.addError(path+".name", "must not be empty")
+		} else if names[value.Name] {
+			v.addError(path+".name", "'%s' is defined more than once", value.Name)
+		}
+
+		if value.Default != "" {
+			v.validateTemplate(path+".default", value.Default, names)
+		}
+
+		names[value.Name] = true
+
+		if value.Pattern != "" {
+			regexp, err := regexp.Compile(value.Pattern)
+			if err != nil {
+				v.addError(path+".pattern", "%s", err)
+			} else {
+				for _, name := range regexp.SubexpNames() {
+					if name != "" {
+						names[name] = true
+					}
+				}
+			}
+		}
+
+		if value.Source != nil {
+			if _, err := regexp.Compile(value.Source.Pattern); err != nil {
+				v.addError(path+".source.pattern", "%s", err)
+			}
+		}
+	}
+
+	for i, target := range config.Targets {
+		path := fmt.Sprintf("$.targets[%d]", i)
+
+		if len(target.Files) == 0 {
+			v.addError(path+".files", "must not be empty")
+		}
+		for j, file := range target.Files {
+			if !doublestar.ValidatePattern(filepath.ToSlash(file)) {
+				v.addError(fmt.Sprintf("%s.files[%d]", path, j), "'%s' is an invalid pattern", file)
+			}
+		}
+		for j, exclude := range target.Excludes {
+			if !doublestar.ValidatePattern(filepath.ToSlash(exclude)) {
+				v.addError(fmt.Sprintf("%s.excludes[%d]", path, j), "'%s' is an invalid pattern", exclude)
+			}
+		}
+
+		if !isValidOnUnchanged(target.OnUnchanged) {
+			v.addError(path+".onUnchanged", "'%s' is an invalid value, it must be ignore, warn or error", target.OnUnchanged)
+		}
+
+		for j, embedded := range target.Embeddeds {
+			embeddedPath := fmt.Sprintf("%s.embeddeds[%d]", path, j)
+
+			// The named groups of the pattern are available as {{.match.name}}.
+			groupNames := map[string]bool{}
+
+			switch embedded.Kind {
+			case "", "regex":
+				if regexp, err := regexp.Compile(embedded.Pattern); err != nil {
+					v.addError(embeddedPath+".pattern", "%s", err)
+				} else {
+					for _, name := range regexp.SubexpNames() {
+						if name != "" {
+							groupNames[name] = true
+						}
+					}
+				}
+			case "block":
+				if embedded.Begin == "" {
+					v.addError(embeddedPath+".begin", "must not be empty for the block kind")
+				}
+				if embedded.End == "" {
+					v.addError(embeddedPath+".end", "must not be empty for the block kind")
+				}
+			case "json", "yaml", "toml":
+				if embedded.Path == "" {
+					v.addError(embeddedPath+".path", "must not be empty for the %s kind", embedded.Kind)
+				} else if _, err := parsePath(embedded.Path); err != nil {
+					v.addError(embeddedPath+".path", "%s", err)
+				}
+			case "xml":
+				if embedded.Path == "" {
+					v.addError(embeddedPath+".path", "must not be empty for the %s kind", embedded.Kind)
+				} else if _, err := parseXPath(embedded.Path); err != nil {
+					v.addError(embeddedPath+".path", "%s", err)
+				}
+			case "properties", "ini", "dotenv":
+				if embedded.Key == "" {
+					v.addError(embeddedPath+".key", "must not be empty for the %s kind", embedded.Kind)
+				}
+			default:
+				v.addError(embeddedPath+".kind", "'%s' is an invalid kind, it must be regex, block, json, yaml, xml, toml, properties, ini or dotenv", embedded.Kind)
+			}
+
+			v.validateReplacement(embeddedPath+".replacement", embedded.Replacement, names, groupNames)
+
+			if _, ok := escapers[embedded.Escape]; !ok {
+				v.addError(embeddedPath+".escape", "'%s' is an invalid escape", embedded.Escape)
+			}
+
+			if embedded.Expect != nil {
+				if err := embedded.Expect.validate(); err != nil {
+					v.addError(embeddedPath+".expect", "%s", err)
+				}
+			}
+		}
+	}
+}
+
+// validateTemplate checks that the template can be parsed and that each {{.name}} refers to a defined name.
+func (v *configValidator) validateTemplate(path string, templStr string, names map[string]bool) {
+
+	templ, err := template.New("template").Funcs(templateFuncs).Parse(templStr)
+	if err != nil {
+		v.addError(path, "%s", err)
+		return
+	}
+
+	if templ.Tree == nil {
+		return
+	}
+
+	for _, name := range templateFieldNames(templ.Tree.Root) {
+		if !names[name] {
+			v.addError(path, "'%s' is not defined in values", name)
+		}
+	}
+}
+
+// validateReplacement checks the replacement template in the same way as validateTemplate,
+// and that each {{.match.name}} refers to a named group of the pattern.
+func (v *configValidator) validateReplacement(path string, templStr string, names map[string]bool, groupNames map[string]bool) {
+
+	templ, err := template.New("template").Funcs(templateFuncs).Parse(templStr)
+	if err != nil {
+		v.addError(path, "%s", err)
+		return
+	}
+
+	if templ.Tree == nil {
+		return
+	}
+
+	for _, field := range templateFields(templ.Tree.Root) {
+		switch {
+		case field[0] != "match":
+			if !names[field[0]] {
+				v.addError(path, "'%s' is not defined in values", field[0])
+			}
+		case len(field) > 1 && !groupNames[field[1]]:
+			v.addError(path, "'%s' is not a named group of the pattern", field[1])
+		}
+	}
+}
+
+// templateFieldNames returns the names referred by {{.name}} in the template.
+// The fields in range and with are not included, because they do not refer to the values.
+func templateFieldNames(node parse.Node) []string {
+
+	names := []string{}
+	for _, field := range templateFields(node) {
+		names = append(names, field[0])
+	}
+
+	return names
+}
+
+// templateFields returns the identifiers of the fields such as {{.name}} and {{.match.name}} in the template.
+func templateFields(node parse.Node) [][]string {
+
+	fields := [][]string{}
+	appendFields := func(node parse.Node) {
+		if node != nil && !reflect.ValueOf(node).IsNil() {
+			fields = append(fields, templateFields(node)...)
+		}
+	}
+
+	switch node := node.(type) {
+	case *parse.ListNode:
+		for _, child := range node.Nodes {
+			appendFields(child)
+		}
+	case *parse.ActionNode:
+		appendFields(node.Pipe)
+	case *parse.PipeNode:
+		for _, cmd := range node.Cmds {
+			appendFields(cmd)
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			appendFields(arg)
+		}
+	case *parse.FieldNode:
+		fields = append(fields, node.Ident)
+	case *parse.ChainNode:
+		appendFields(node.Node)
+	case *parse.IfNode:
+		appendFields(node.Pipe)
+		appendFields(node.List)
+		appendFields(node.ElseList)
+	case *parse.RangeNode:
+		appendFields(node.Pipe)
+		appendFields(node.ElseList)
+	case *parse.WithNode:
+		appendFields(node.Pipe)
+		appendFields(node.ElseList)
+	}
+
+	return fields
+}
+
+func parseConfigNode(path string, content []byte) (*configNode, error) {
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var document yaml.Node
+		if err := yaml.Unmarshal(content, &document); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if len(document.Content) == 0 {
+			return &configNode{Kind: objectNode}, nil
+		}
+		return yamlConfigNode(document.Content[0]), nil
+	case ".toml":
+		var config interface{}
+		if err := toml.Unmarshal(content, &config); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		// The TOML parser does not provide the positions of the values.
+		return valueConfigNode(config), nil
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		return jsonConfigNode(decoder, content)
+	}
+}
+
+func jsonConfigNode(decoder *json.Decoder, content []byte) (*configNode, error) {
+
+	line, column := position(content, jsonTokenStart(content, int(decoder.InputOffset())))
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	node := &configNode{
+		Line:   line,
+		Column: column,
+	}
+
+	switch token {
+	case json.Delim('{'):
+		node.Kind = objectNode
+		for decoder.More() {
+			keyLine, keyColumn := position(content, jsonTokenStart(content, int(decoder.InputOffset())))
+
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			value, err := jsonConfigNode(decoder, content)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Fields = append(node.Fields, configField{
+				Key:    key.(string),
+				Line:   keyLine,
+				Column: keyColumn,
+				Value:  value,
+			})
+		}
+	case json.Delim('['):
+		node.Kind = arrayNode
+		for decoder.More() {
+			item, err := jsonConfigNode(decoder, content)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Items = append(node.Items, item)
+		}
+	default:
+		node.Kind = scalarNode
+		node.Value = token
+		return node, nil
+	}
+
+	// The closing delimiter.
+	if _, err := decoder.Token(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return node, nil
+}
+
+// jsonTokenStart skips the whitespaces and the separators before the next token.
+func jsonTokenStart(content []byte, offset int) int {
+
+	for offset < len(content) && strings.IndexByte(" \t\r\n,:", content[offset]) >= 0 {
+		offset++
+	}
+
+	return offset
+}
+
+// position returns the line and the column (1-based) of the offset.
+func position(content []byte, offset int) (int, int) {
+
+	before := content[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(before, '\n')
+
+	return line, column
+}
+
+func yamlConfigNode(node *yaml.Node) *configNode {
+
+	if node.Kind == yaml.AliasNode {
+		return yamlConfigNode(node.Alias)
+	}
+
+	configNode := &configNode{
+		Line:   node.Line,
+		Column: node.Column,
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		configNode.Kind = objectNode
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			configNode.Fields = append(configNode.Fields, configField{
+				Key:    key.Value,
+				Line:   key.Line,
+				Column: key.Column,
+				Value:  yamlConfigNode(node.Content[i+1]),
+			})
+		}
+	case yaml.SequenceNode:
+		configNode.Kind = arrayNode
+		for _, item := range node.Content {
+			configNode.Items = append(configNode.Items, yamlConfigNode(item))
+		}
+	default:
+		configNode.Kind = scalarNode
+		switch node.ShortTag() {
+		case "!!null":
+			configNode.Value = nil
+		case "!!bool":
+			configNode.Value = node.Value == "true"
+		case "!!int", "!!float":
+			configNode.Value = json.Number(node.Value)
+		default:
+			configNode.Value = node.Value
+		}
+	}
+
+	return configNode
+}
+
+func valueConfigNode(value interface{}) *configNode {
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		node := &configNode{Kind: objectNode}
+		for _, key := range keys {
+			node.Fields = append(node.Fields, configField{
+				Key:   key,
+				Value: valueConfigNode(value[key]),
+			})
+		}
+		return node
+	case []interface{}:
+		node := &configNode{Kind: arrayNode}
+		for _, item := range value {
+			node.Items = append(node.Items, valueConfigNode(item))
+		}
+		return node
+	case int64, float64:
+		return &configNode{Kind: scalarNode, Value: json.Number(fmt.Sprint(value))}
+	default:
+		return &configNode{Kind: scalarNode, Value: value}
+	}
+}
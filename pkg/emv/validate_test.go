@@ -0,0 +1,251 @@
+package emv
+
+import (
+	"reflect"
+	"testing"
+	"text/template"
+	"text/template/parse"
+)
+
+func TestValidateConfig(t *testing.T) {
+
+	config := `{
+  "value" : [],
+  "values" : [
+    { "name" : "version", "pattern" : "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)$" },
+    { "name" : "date", "pattern" : "^(" }
+  ],
+  "targets" : [
+    {
+      "files" : [ "a.txt", "[a-" ],
+      "embedded" : [],
+      "embeddeds" : [
+        { "pattern" : "v=[0-9", "replacement" : "v={{.versoin}}.{{.minor}}" },
+        { "pattern" : "d=.+", "replacement" : "d={{.date", "escape" : "csv" }
+      ]
+    }
+  ]
+}`
+
+	result, err := ValidateConfig("emv.json", []byte(config))
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []ValidationError{
+		{Path: "$.value", Line: 2, Column: 3, Message: "unknown field 'value'"},
+		{Path: "$.targets[0].embedded", Line: 10, Column: 7, Message: "unknown field 'embedded'"},
+		{Path: "$.values[1].pattern", Line: 5, Column: 36, Message: "error parsing regexp: missing closing ): `^(`"},
+		{Path: "$.targets[0].files[1]", Line: 9, Column: 28, Message: "'[a-' is an invalid pattern"},
+		{Path: "$.targets[0].embeddeds[0].pattern", Line: 12, Column: 23, Message: "error parsing regexp: missing closing ]: `[0-9`"},
+		{Path: "$.targets[0].embeddeds[0].replacement", Line: 12, Column: 49, Message: "'versoin' is not defined in values"},
+		{Path: "$.targets[0].embeddeds[1].replacement", Line: 13, Column: 47, Message: "template: template:1: unclosed action"},
+		{Path: "$.targets[0].embeddeds[1].escape", Line: 13, Column: 71, Message: "'csv' is an invalid escape"},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestValidateConfig_types(t *testing.T) {
+
+	config := `{
+  "values" : { "name" : "version" },
+  "targets" : [
+    {
+      "files" : "a.txt",
+      "embeddeds" : [
+        { "pattern" : 1, "replacement" : "v" }
+      ]
+    }
+  ]
+}`
+
+	result, err := ValidateConfig("emv.json", []byte(config))
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []ValidationError{
+		{Path: "$.values", Line: 2, Column: 14, Message: "must be an array"},
+		{Path: "$.targets[0].files", Line: 5, Column: 17, Message: "must be an array"},
+		{Path: "$.targets[0].embeddeds[0].pattern", Line: 7, Column: 23, Message: "must be a string"},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestValidateConfig_matchGroups(t *testing.T) {
+
+	config := `{
+  "values" : [ { "name" : "version" } ],
+  "targets" : [
+    {
+      "files" : [ "a.txt" ],
+      "embeddeds" : [
+        { "pattern" : "(?P<indent>\\s*)v=.+", "replacement" : "{{.match.indent}}v={{.version}}" },
+        { "pattern" : "(?P<indent>\\s*)v=.+", "replacement" : "{{.match.ident}}v={{.version}}" }
+      ]
+    }
+  ]
+}`
+
+	result, err := ValidateConfig("emv.json", []byte(config))
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []ValidationError{
+		{Path: "$.targets[0].embeddeds[1].replacement", Line: 8, Column: 63, Message: "'ident' is not a named group of the pattern"},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestValidateConfig_kind(t *testing.T) {
+
+	config := `{
+  "values" : [ { "name" : "version" } ],
+  "targets" : [
+    {
+      "files" : [ "a.txt" ],
+      "embeddeds" : [
+        { "kind" : "block", "begin" : "<!-- begin -->", "end" : "<!-- end -->", "replacement" : "{{.version}}" },
+        { "kind" : "block", "begin" : "<!-- begin -->", "replacement" : "{{.version}}" },
+        { "kind" : "csv", "replacement" : "{{.version}}" },
+        { "kind" : "json", "path" : "$.version", "replacement" : "{{.version}}" },
+        { "kind" : "json", "replacement" : "{{.version}}" },
+        { "kind" : "json", "path" : "a[x]", "replacement" : "{{.version}}" },
+        { "kind" : "yaml", "path" : "image.tag", "replacement" : "{{.version}}" },
+        { "kind" : "xml", "path" : "project/version", "replacement" : "{{.version}}" },
+        { "kind" : "toml", "path" : "package.version", "replacement" : "{{.version}}" },
+        { "kind" : "ini", "section" : "app", "key" : "version", "replacement" : "{{.version}}" },
+        { "kind" : "dotenv", "replacement" : "{{.version}}" }
+      ]
+    }
+  ]
+}`
+
+	result, err := ValidateConfig("emv.json", []byte(config))
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []ValidationError{
+		{Path: "$.targets[0].embeddeds[1].end", Message: "must not be empty for the block kind"},
+		{Path: "$.targets[0].embeddeds[2].kind", Line: 9, Column: 20, Message: "'csv' is an invalid kind, it must be regex, block, json, yaml, xml, toml, properties, ini or dotenv"},
+		{Path: "$.targets[0].embeddeds[4].path", Message: "must not be empty for the json kind"},
+		{Path: "$.targets[0].embeddeds[5].path", Line: 12, Column: 37, Message: "'a[x]' is an invalid path: '[x]' must be an index, a quoted key or a selector such as [name=value]"},
+		{Path: "$.targets[0].embeddeds[7].path", Line: 14, Column: 36, Message: "'project/version' must start with /"},
+		{Path: "$.targets[0].embeddeds[10].key", Message: "must not be empty for the dotenv kind"},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestValidateConfig_yaml(t *testing.T) {
+
+	config := `values:
+  - name: version
+    default: "{{.date}}"
+targets:
+  - files: [a.txt]
+    embeddeds:
+      - pattern: v=.+
+        replacement: v={{.version}}
+        replacment: v={{.version}}
+`
+
+	result, err := ValidateConfig("emv.yaml", []byte(config))
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []ValidationError{
+		{Path: "$.targets[0].embeddeds[0].replacment", Line: 9, Column: 9, Message: "unknown field 'replacment'"},
+		{Path: "$.values[0].default", Line: 3, Column: 14, Message: "'date' is not defined in values"},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestValidateConfig_toml(t *testing.T) {
+
+	config := `
+[[values]]
+name = "version"
+
+[[targets]]
+file = ["a.txt"]
+`
+
+	result, err := ValidateConfig("emv.toml", []byte(config))
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []ValidationError{
+		{Path: "$.targets[0].file", Message: "unknown field 'file'"},
+		{Path: "$.targets[0].files", Message: "must not be empty"},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestValidateConfig_empty(t *testing.T) {
+
+	result, err := ValidateConfig("emv.json", []byte(`{}`))
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []ValidationError{
+		{Path: "$.values", Message: "must not be empty"},
+		{Path: "$.targets", Message: "must not be empty"},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestValidateConfig_invalidJSON(t *testing.T) {
+
+	_, err := ValidateConfig("emv.json", []byte(`{"values": [}`))
+	if err == nil {
+		t.Fatal("failed test")
+	}
+}
+
+func TestTemplateFieldNames(t *testing.T) {
+
+	templ := parseTestTemplate(t, `{{.a}} {{if .b}}{{.c}}{{else}}{{.d.x}}{{end}} {{range .e}}{{.f}}{{end}} {{printf "%s" .g | printf "%s"}}`)
+
+	result := templateFieldNames(templ)
+
+	expect := []string{"a", "b", "c", "d", "e", "g"}
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func parseTestTemplate(t *testing.T, templStr string) *parse.ListNode {
+
+	templ, err := template.New("template").Parse(templStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return templ.Tree.Root
+}
@@ -0,0 +1,193 @@
+package emv
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+func values(args []string, namedValues map[string]string, valueConfigs []Value) (map[string]string, error) {
+
+	inputs := map[string]string{}
+
+	unknownNames := []string{}
+	for name, value := range namedValues {
+		if !hasValueConfig(valueConfigs, name) {
+			unknownNames = append(unknownNames, name)
+		}
+		inputs[name] = value
+	}
+
+	if len(unknownNames) != 0 {
+		sort.Strings(unknownNames)
+		return nil, errors.Errorf("unknown values: %s", strings.Join(unknownNames, ", "))
+	}
+
+	// Arguments are assigned in order to the values that are not specified by name.
+	argIndex := 0
+	for _, valueConfig := range valueConfigs {
+		if _, ok := inputs[valueConfig.Name]; ok {
+			continue
+		}
+
+		if argIndex < len(args) {
+			inputs[valueConfig.Name] = args[argIndex]
+			argIndex++
+		}
+	}
+
+	if argIndex < len(args) {
+		return nil, errors.Errorf("too many arguments: %s", strings.Join(args[argIndex:], " "))
+	}
+
+	values := map[string]string{}
+	missingNames := []string{}
+
+	for _, valueConfig := range valueConfigs {
+
+		value, err := resolveValue(valueConfig, inputs, values)
+		if err != nil {
+			return nil, err
+		}
+
+		unspecified := value == nil
+		if unspecified {
+			if valueConfig.Required == nil || *valueConfig.Required {
+				missingNames = append(missingNames, valueConfig.Name)
+				continue
+			}
+
+			// An optional value that is not specified is empty.
+			empty := ""
+			value = &empty
+		}
+
+		values[valueConfig.Name] = *value
+
+		if valueConfig.Pattern != "" {
+
+			regexp, err := regexp.Compile(valueConfig.Pattern)
+			if err != nil {
+				return nil, errors.Wrapf(err, "'%s' in values-pattern is an invalid value", valueConfig.Pattern)
+			}
+
+			match := regexp.FindStringSubmatch(*value)
+			if match == nil {
+				if !unspecified {
+					return nil, errors.Errorf("'%s' does not match the pattern: %s", *value, valueConfig.Pattern)
+				}
+
+				match = make([]string, regexp.NumSubexp()+1)
+			}
+
+			for i, name := range regexp.SubexpNames() {
+				if i != 0 && name != "" {
+					values[name] = match[i]
+				}
+			}
+		}
+	}
+
+	if len(missingNames) != 0 {
+		return nil, errors.Errorf("missing values: %s", strings.Join(missingNames, ", "))
+	}
+
+	return values, nil
+}
+
+// resolveValue returns the value in the order of the input, the environment variable and the default.
+// If none of them, it returns nil.
+func resolveValue(valueConfig Value, inputs map[string]string, values map[string]string) (*string, error) {
+
+	if value, ok := inputs[valueConfig.Name]; ok {
+		return &value, nil
+	}
+
+	if valueConfig.Env != "" {
+		if value := os.Getenv(valueConfig.Env); value != "" {
+			return &value, nil
+		}
+	}
+
+	if valueConfig.Default != "" {
+		// The default can refer to the values defined before it.
+		value, err := executeTemplate(valueConfig.Default, values)
+		if err != nil {
+			return nil, errors.Wrapf(err, "'%s' in values-default is an invalid value", valueConfig.Default)
+		}
+
+		return &value, nil
+	}
+
+	return nil, nil
+}
+
+// bump returns the name, the current version and the next version of the value that has the source.
+func bump(fsys FS, valueConfigs []Value, part string, baseDirPath string) (string, string, string, error) {
+
+	var valueConfig *Value
+	for i := range valueConfigs {
+		if valueConfigs[i].Source != nil {
+			if valueConfig != nil {
+				return "", "", "", errors.Errorf("only one value can have a source to bump")
+			}
+			valueConfig = &valueConfigs[i]
+		}
+	}
+
+	if valueConfig == nil {
+		return "", "", "", errors.Errorf("no value has a source to read the current version")
+	}
+
+	current, err := readSource(fsys, *valueConfig.Source, baseDirPath)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	next, err := bumpVersion(current, part)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return valueConfig.Name, current, next, nil
+}
+
+// readSource reads the current value from the source file.
+// It is the first group of the pattern, or the whole match if the pattern has no group.
+func readSource(fsys FS, source Source, baseDirPath string) (string, error) {
+
+	regexp, err := regexp.Compile(source.Pattern)
+	if err != nil {
+		return "", errors.Wrapf(err, "'%s' in values-source-pattern is an invalid value", source.Pattern)
+	}
+
+	content, err := fsys.ReadFile(resolvePath(source.File, baseDirPath))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	match := regexp.FindStringSubmatch(string(content))
+	if match == nil {
+		return "", errors.Errorf("'%s' in values-source-pattern did not match in %s", source.Pattern, source.File)
+	}
+
+	if len(match) > 1 {
+		return match[1], nil
+	}
+
+	return match[0], nil
+}
+
+func hasValueConfig(valueConfigs []Value, name string) bool {
+
+	for _, valueConfig := range valueConfigs {
+		if valueConfig.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,404 @@
+package emv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValues(t *testing.T) {
+
+	args := []string{
+		"10.0.3",
+		"x",
+	}
+	valueConfigs := []Value{
+		{
+			Name:    "version",
+			Pattern: "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)\\.(?P<revision>[0-9]+)$",
+		},
+		{
+			Name: "val2",
+		},
+	}
+
+	result, err := values(args, nil, valueConfigs)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := map[string]string{
+		"version":  "10.0.3",
+		"major":    "10",
+		"minor":    "0",
+		"revision": "3",
+		"val2":     "x",
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestValues_invalidRegex(t *testing.T) {
+
+	args := []string{
+		"10.0.3",
+	}
+	valueConfigs := []Value{
+		{
+			Name:    "version",
+			Pattern: "^(",
+		},
+	}
+
+	_, err := values(args, nil, valueConfigs)
+	if err.Error() != "'^(' in values-pattern is an invalid value: error parsing regexp: missing closing ): `^(`" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestValues_unmatchRegex(t *testing.T) {
+
+	args := []string{
+		"10.0.3",
+	}
+	valueConfigs := []Value{
+		{
+			Name:    "version",
+			Pattern: "^[0-9]+$",
+		},
+	}
+
+	_, err := values(args, nil, valueConfigs)
+	if err.Error() != "'10.0.3' does not match the pattern: ^[0-9]+$" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestValues_named(t *testing.T) {
+
+	args := []string{
+		"x",
+		"z",
+	}
+	namedValues := map[string]string{
+		"version": "10.0.3",
+		"val3":    "y",
+	}
+	valueConfigs := []Value{
+		{
+			Name:    "version",
+			Pattern: "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)\\.(?P<revision>[0-9]+)$",
+		},
+		{
+			Name: "val2",
+		},
+		{
+			Name: "val3",
+		},
+		{
+			Name: "val4",
+		},
+	}
+
+	result, err := values(args, namedValues, valueConfigs)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := map[string]string{
+		"version":  "10.0.3",
+		"major":    "10",
+		"minor":    "0",
+		"revision": "3",
+		"val2":     "x",
+		"val3":     "y",
+		"val4":     "z",
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestValues_missing(t *testing.T) {
+
+	args := []string{
+		"x",
+	}
+	valueConfigs := []Value{
+		{
+			Name: "val1",
+		},
+		{
+			Name: "val2",
+		},
+		{
+			Name: "val3",
+		},
+	}
+
+	_, err := values(args, nil, valueConfigs)
+	if err.Error() != "missing values: val2, val3" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestValues_unknown(t *testing.T) {
+
+	namedValues := map[string]string{
+		"val1": "x",
+		"vel2": "y",
+		"val3": "z",
+	}
+	valueConfigs := []Value{
+		{
+			Name: "val1",
+		},
+		{
+			Name: "val2",
+		},
+	}
+
+	_, err := values(nil, namedValues, valueConfigs)
+	if err.Error() != "unknown values: val3, vel2" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestValues_tooManyArguments(t *testing.T) {
+
+	args := []string{
+		"x",
+		"y",
+		"z",
+	}
+	valueConfigs := []Value{
+		{
+			Name: "val1",
+		},
+	}
+
+	_, err := values(args, nil, valueConfigs)
+	if err.Error() != "too many arguments: y z" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestValues_default(t *testing.T) {
+
+	valueConfigs := []Value{
+		{
+			Name:    "version",
+			Pattern: "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)\\.(?P<revision>[0-9]+)$",
+			Default: "1.2.3",
+		},
+		{
+			Name:    "tag",
+			Default: "v{{.version}}-{{.major}}",
+		},
+		{
+			Name:    "date",
+			Default: `{{now | date "2006-01-02"}}`,
+		},
+		{
+			Name:    "val4",
+			Default: "x",
+		},
+	}
+
+	result, err := values(nil, map[string]string{"val4": "y"}, valueConfigs)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := map[string]string{
+		"version":  "1.2.3",
+		"major":    "1",
+		"minor":    "2",
+		"revision": "3",
+		"tag":      "v1.2.3-1",
+		"date":     time.Now().Format("2006-01-02"),
+		"val4":     "y",
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestValues_env(t *testing.T) {
+
+	os.Setenv("EMV_TEST_BUILD_NUMBER", "123")
+	defer os.Unsetenv("EMV_TEST_BUILD_NUMBER")
+	os.Unsetenv("EMV_TEST_NONE")
+
+	valueConfigs := []Value{
+		{
+			Name: "buildNumber",
+			Env:  "EMV_TEST_BUILD_NUMBER",
+		},
+		{
+			Name:    "val2",
+			Env:     "EMV_TEST_NONE",
+			Default: "0",
+		},
+		{
+			Name: "val3",
+			Env:  "EMV_TEST_BUILD_NUMBER",
+		},
+	}
+
+	result, err := values(nil, map[string]string{"val3": "z"}, valueConfigs)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := map[string]string{
+		"buildNumber": "123",
+		"val2":        "0",
+		"val3":        "z",
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestValues_envNotSet(t *testing.T) {
+
+	os.Unsetenv("EMV_TEST_NONE")
+
+	valueConfigs := []Value{
+		{
+			Name: "buildNumber",
+			Env:  "EMV_TEST_NONE",
+		},
+	}
+
+	_, err := values(nil, nil, valueConfigs)
+	if err.Error() != "missing values: buildNumber" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestValues_optional(t *testing.T) {
+
+	optional := false
+	valueConfigs := []Value{
+		{
+			Name:     "version",
+			Pattern:  "^(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)$",
+			Required: &optional,
+		},
+		{
+			Name:     "val2",
+			Required: &optional,
+		},
+	}
+
+	result, err := values(nil, nil, valueConfigs)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := map[string]string{
+		"version": "",
+		"major":   "",
+		"minor":   "",
+		"val2":    "",
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestValues_invalidDefault(t *testing.T) {
+
+	valueConfigs := []Value{
+		{
+			Name:    "val1",
+			Default: "{{.x",
+		},
+	}
+
+	_, err := values(nil, nil, valueConfigs)
+	if err == nil || !strings.HasPrefix(err.Error(), "'{{.x' in values-default is an invalid value: ") {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestBump_noSource(t *testing.T) {
+
+	valueConfigs := []Value{
+		{
+			Name: "version",
+		},
+	}
+
+	_, _, _, err := bump(MapFS{}, valueConfigs, "patch", "")
+	if err == nil || err.Error() != "no value has a source to read the current version" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestBump_multipleSources(t *testing.T) {
+
+	valueConfigs := []Value{
+		{
+			Name:   "version1",
+			Source: &Source{File: "a", Pattern: "a"},
+		},
+		{
+			Name:   "version2",
+			Source: &Source{File: "b", Pattern: "b"},
+		},
+	}
+
+	_, _, _, err := bump(MapFS{}, valueConfigs, "patch", "")
+	if err == nil || err.Error() != "only one value can have a source to bump" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
+
+func TestReadSource(t *testing.T) {
+
+	file := filepath.Join("dir", "version.xml")
+	fsys := MapFS{file: "<version>1.0.0</version>"}
+
+	{
+		result, err := readSource(fsys, Source{File: "version.xml", Pattern: "<version>(.+)</version>"}, "dir")
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		if result != "1.0.0" {
+			t.Fatal("failed test\n", result)
+		}
+	}
+	{
+		result, err := readSource(fsys, Source{File: file, Pattern: "[0-9.]+"}, "")
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		if result != "1.0.0" {
+			t.Fatal("failed test\n", result)
+		}
+	}
+}
+
+func TestReadSource_unmatch(t *testing.T) {
+
+	fsys := MapFS{"version.xml": "<version>1.0.0</version>"}
+
+	_, err := readSource(fsys, Source{File: "version.xml", Pattern: "version=(.+)"}, "")
+	if err == nil || err.Error() != "'version=(.+)' in values-source-pattern did not match in version.xml" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+}
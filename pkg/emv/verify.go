@@ -0,0 +1,75 @@
+package emv
+
+import "sort"
+
+type Consistency struct {
+	Name        string       `json:"name"`
+	Consistent  bool         `json:"consistent"`
+	Occurrences []Occurrence `json:"occurrences"`
+}
+
+type Occurrence struct {
+	Value string `json:"value"`
+	File  string `json:"file"`
+	Line  int    `json:"line"`
+}
+
+// Consistencies groups the extracted values by name. The occurrences are ordered by value.
+func Consistencies(fileExtractions []FileExtraction) []Consistency {
+
+	occurrencesByName := map[string][]Occurrence{}
+
+	for _, fileExtraction := range fileExtractions {
+		for _, extraction := range fileExtraction.Rules {
+			for _, match := range extraction.Matches {
+				for name, value := range match.Values {
+					occurrencesByName[name] = append(occurrencesByName[name], Occurrence{
+						Value: value,
+						File:  fileExtraction.File,
+						Line:  match.Line,
+					})
+
+					// The different values captured for the same name in a match are also inconsistent.
+					for _, conflict := range match.Conflicts[name] {
+						occurrencesByName[name] = append(occurrencesByName[name], Occurrence{
+							Value: conflict,
+							File:  fileExtraction.File,
+							Line:  match.Line,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	names := []string{}
+	for name := range occurrencesByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	consistencies := []Consistency{}
+	for _, name := range names {
+
+		occurrences := occurrencesByName[name]
+
+		// Group the same values, keeping the order of appearance.
+		order := map[string]int{}
+		for _, occurrence := range occurrences {
+			if _, ok := order[occurrence.Value]; !ok {
+				order[occurrence.Value] = len(order)
+			}
+		}
+		sort.SliceStable(occurrences, func(i, j int) bool {
+			return order[occurrences[i].Value] < order[occurrences[j].Value]
+		})
+
+		consistencies = append(consistencies, Consistency{
+			Name:        name,
+			Consistent:  len(order) == 1,
+			Occurrences: occurrences,
+		})
+	}
+
+	return consistencies
+}
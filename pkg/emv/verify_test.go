@@ -0,0 +1,96 @@
+package emv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConsistencies(t *testing.T) {
+
+	fileExtractions := []FileExtraction{
+		{
+			File: "a",
+			Rules: []Extraction{
+				{
+					Matches: []MatchValue{
+						{Line: 1, Values: map[string]string{"major": "1"}},
+						{Line: 2, Values: map[string]string{"major": "2", "minor": "0"}},
+					},
+				},
+			},
+		},
+		{
+			File: "b",
+			Rules: []Extraction{
+				{
+					Matches: []MatchValue{
+						{Line: 3, Values: map[string]string{"major": "1", "minor": "0"}},
+					},
+				},
+			},
+		},
+	}
+
+	result := Consistencies(fileExtractions)
+
+	expect := []Consistency{
+		{
+			Name:       "major",
+			Consistent: false,
+			Occurrences: []Occurrence{
+				{Value: "1", File: "a", Line: 1},
+				{Value: "1", File: "b", Line: 3},
+				{Value: "2", File: "a", Line: 2},
+			},
+		},
+		{
+			Name:       "minor",
+			Consistent: true,
+			Occurrences: []Occurrence{
+				{Value: "0", File: "a", Line: 2},
+				{Value: "0", File: "b", Line: 3},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
+
+func TestConsistencies_conflicts(t *testing.T) {
+
+	fileExtractions := []FileExtraction{
+		{
+			File: "a",
+			Rules: []Extraction{
+				{
+					Matches: []MatchValue{
+						{
+							Line:      1,
+							Values:    map[string]string{"version": "2.0.0"},
+							Conflicts: map[string][]string{"version": {"1.9.0"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	result := Consistencies(fileExtractions)
+
+	expect := []Consistency{
+		{
+			Name:       "version",
+			Consistent: false,
+			Occurrences: []Occurrence{
+				{Value: "2.0.0", File: "a", Line: 1},
+				{Value: "1.9.0", File: "a", Line: 1},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatal("failed test\n", result)
+	}
+}
@@ -0,0 +1,506 @@
+package emv
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/onozaty/emv/internal/strutil"
+	"github.com/pkg/errors"
+)
+
+// xmlLocator finds the text of the elements or the values of the attributes selected by the XPath.
+// Only the text of the values is replaced, so that the formatting and the other elements are kept.
+type xmlLocator struct {
+	steps []xpathStep
+}
+
+func (l *xmlLocator) locate(content string) ([]place, error) {
+
+	root, err := parseXMLElements(content)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := []*xmlElement{root}
+	var attributes []*xmlAttribute
+
+	for _, step := range l.steps {
+
+		if step.attribute {
+			attributes = []*xmlAttribute{}
+			for _, element := range elements {
+				for _, attribute := range element.attributes {
+					if step.name == "*" || attribute.name == step.name {
+						attributes = append(attributes, attribute)
+					}
+				}
+			}
+			break
+		}
+
+		if step.text {
+			break
+		}
+
+		elements = step.find(elements)
+	}
+
+	places := []place{}
+
+	if attributes != nil {
+		for _, attribute := range attributes {
+			quote := attribute.quote
+			places = append(places, place{
+				start: attribute.start,
+				end:   attribute.end,
+				text:  attribute.value,
+				encode: func(replacement string) (string, error) {
+					return escapeXMLAttribute(replacement, quote), nil
+				},
+			})
+		}
+	} else {
+		for _, element := range elements {
+			place, err := element.place(content)
+			if err != nil {
+				return nil, err
+			}
+			places = append(places, place)
+		}
+	}
+
+	sort.Slice(places, func(i, j int) bool { return places[i].start < places[j].start })
+
+	return places, nil
+}
+
+type xmlElement struct {
+	// name is the qualified name as written. (e.g. project, android:label)
+	name       string
+	attributes []*xmlAttribute
+	children   []*xmlElement
+	// start and end are the offsets of the element, including the tags.
+	start int
+	end   int
+	// contentStart and contentEnd are the offsets between the start tag and the end tag.
+	contentStart int
+	contentEnd   int
+	text         string
+	selfClosing  bool
+}
+
+type xmlAttribute struct {
+	name  string
+	value string
+	// start and end are the offsets of the value, without the quotes.
+	start int
+	end   int
+	quote byte
+}
+
+// parseXMLElements parses the XML with the offsets of the elements,
+// and returns the document as the parent of the root element.
+func parseXMLElements(content string) (*xmlElement, error) {
+
+	document := &xmlElement{}
+	stack := []*xmlElement{document}
+
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	for {
+		start := int(decoder.InputOffset())
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse as XML")
+		}
+		end := int(decoder.InputOffset())
+
+		parent := stack[len(stack)-1]
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			element := &xmlElement{
+				name:         xmlName(token.Name),
+				start:        start,
+				contentStart: end,
+				selfClosing:  strings.HasSuffix(content[start:end], "/>"),
+			}
+			element.attributes = xmlAttributes(content, start, end, token.Attr)
+
+			parent.children = append(parent.children, element)
+			stack = append(stack, element)
+		case xml.EndElement:
+			if len(stack) == 1 || xmlName(token.Name) != parent.name {
+				return nil, errors.Errorf("failed to parse as XML: unexpected end element </%s> in line %d", xmlName(token.Name), lineNumber(content, start))
+			}
+
+			parent.end = end
+			parent.contentEnd = start
+			if parent.selfClosing {
+				// The end element of <a/> is not in the content.
+				parent.end = parent.contentStart
+				parent.contentEnd = parent.contentStart
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			parent.text += string(token)
+		}
+	}
+
+	if len(stack) != 1 {
+		return nil, errors.Errorf("failed to parse as XML: element <%s> is not closed", stack[len(stack)-1].name)
+	}
+
+	return document, nil
+}
+
+func xmlName(name xml.Name) string {
+
+	if name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+
+	return name.Local
+}
+
+// xmlAttributes finds the offsets of the attribute values in the start tag.
+func xmlAttributes(content string, start int, end int, attrs []xml.Attr) []*xmlAttribute {
+
+	attributes := []*xmlAttribute{}
+
+	pos := start + 1
+	// The name of the element
+	for pos < end && !isXMLSpace(content[pos]) && content[pos] != '>' && content[pos] != '/' {
+		pos++
+	}
+
+	for _, attr := range attrs {
+
+		quoteStart := strings.IndexAny(content[pos:end], `"'`)
+		if quoteStart == -1 {
+			break
+		}
+		quoteStart += pos
+
+		quote := content[quoteStart]
+		valueEnd := quoteStart + 1 + strings.IndexByte(content[quoteStart+1:end], quote)
+
+		attributes = append(attributes, &xmlAttribute{
+			name:  xmlName(attr.Name),
+			value: attr.Value,
+			start: quoteStart + 1,
+			end:   valueEnd,
+			quote: quote,
+		})
+		pos = valueEnd + 1
+	}
+
+	return attributes
+}
+
+func isXMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// place returns the text of the element. The spaces around the text are kept.
+func (e *xmlElement) place(content string) (place, error) {
+
+	if len(e.children) != 0 {
+		return place{}, errors.Errorf("the element <%s> in line %d has child elements", e.name, lineNumber(content, e.start))
+	}
+
+	if e.selfClosing {
+		// <a/> is replaced with <a>text</a>.
+		tag := strings.TrimRight(strings.TrimSuffix(content[e.start:e.end], "/>"), " \t\r\n")
+		name := e.name
+		return place{
+			start: e.start,
+			end:   e.end,
+			encode: func(replacement string) (string, error) {
+				if replacement == "" {
+					return tag + "/>", nil
+				}
+				return tag + ">" + escapeXMLText(replacement) + "</" + name + ">", nil
+			},
+		}, nil
+	}
+
+	raw := content[e.contentStart:e.contentEnd]
+	start := e.contentStart + len(raw) - len(strings.TrimLeft(raw, " \t\r\n"))
+	end := e.contentEnd - (len(raw) - len(strings.TrimRight(raw, " \t\r\n")))
+	if end < start {
+		end = start
+	}
+
+	cdata := strings.HasPrefix(content[start:end], "<![CDATA[") && strings.HasSuffix(content[start:end], "]]>")
+
+	return place{
+		start: start,
+		end:   end,
+		text:  strings.TrimSpace(e.text),
+		encode: func(replacement string) (string, error) {
+			if cdata && !strings.Contains(replacement, "]]>") {
+				return "<![CDATA[" + replacement + "]]>", nil
+			}
+			return escapeXMLText(replacement), nil
+		},
+	}, nil
+}
+
+var xmlTextReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escapeXMLText(text string) string {
+	return xmlTextReplacer.Replace(text)
+}
+
+func escapeXMLAttribute(text string, quote byte) string {
+
+	text = escapeXMLText(text)
+	if quote == '"' {
+		return strings.ReplaceAll(text, `"`, "&quot;")
+	}
+
+	return strings.ReplaceAll(text, "'", "&apos;")
+}
+
+// xpathStep is a step of the XPath such as /name, //name[1], /name[child='value'] or /@name.
+type xpathStep struct {
+	// descendant is true for //.
+	descendant bool
+	// name is the qualified name as written in the file, or *.
+	name string
+	// attribute is true for @name, text is true for text().
+	attribute  bool
+	text       bool
+	predicates []xpathPredicate
+}
+
+// xpathPredicate is [1], [name='value'], [@name='value'], [name] or [@name].
+type xpathPredicate struct {
+	// position is 1-based, or 0 if it is not a position.
+	position  int
+	name      string
+	attribute bool
+	value     string
+	hasValue  bool
+}
+
+// parseXPath parses a subset of XPath. (e.g. /project/version, //plugin[artifactId='x']/version, /manifest/@android:versionName)
+func parseXPath(path string) ([]xpathStep, error) {
+
+	if !strings.HasPrefix(path, "/") {
+		return nil, errors.Errorf("'%s' must start with /", path)
+	}
+
+	steps := []xpathStep{}
+	rest := path
+
+	for rest != "" {
+
+		if len(steps) != 0 && (steps[len(steps)-1].attribute || steps[len(steps)-1].text) {
+			return nil, errors.Errorf("an attribute or text() must be the last step in '%s'", path)
+		}
+
+		step := xpathStep{}
+		if strings.HasPrefix(rest, "//") {
+			step.descendant = true
+			rest = rest[2:]
+		} else if strings.HasPrefix(rest, "/") {
+			rest = rest[1:]
+		} else {
+			return nil, errors.Errorf("'/' is expected in '%s'", path)
+		}
+
+		end := strings.IndexAny(rest, "/[")
+		if end == -1 {
+			end = len(rest)
+		}
+		name := rest[:end]
+		rest = rest[end:]
+
+		switch {
+		case name == "text()":
+			step.text = true
+		case strings.HasPrefix(name, "@"):
+			step.attribute = true
+			step.name = name[1:]
+		default:
+			step.name = name
+		}
+		if !step.text && step.name == "" {
+			return nil, errors.Errorf("a name is empty in '%s'", path)
+		}
+
+		for strings.HasPrefix(rest, "[") {
+			end := xpathPredicateEnd(rest)
+			if end == -1 {
+				return nil, errors.Errorf("']' is missing in '%s'", path)
+			}
+
+			predicate, err := parseXPathPredicate(strings.TrimSpace(rest[1:end]))
+			if err != nil {
+				return nil, errors.Wrapf(err, "'%s' is an invalid XPath", path)
+			}
+			step.predicates = append(step.predicates, predicate)
+			rest = rest[end+1:]
+		}
+
+		steps = append(steps, step)
+	}
+
+	if len(steps) == 0 {
+		return nil, errors.Errorf("'%s' has no steps", path)
+	}
+
+	return steps, nil
+}
+
+// xpathPredicateEnd returns the index of ] of the predicate, ignoring ] in the quotes.
+func xpathPredicateEnd(text string) int {
+
+	var quote byte
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote != 0:
+			if text[i] == quote {
+				quote = 0
+			}
+		case text[i] == '\'' || text[i] == '"':
+			quote = text[i]
+		case text[i] == ']':
+			return i
+		}
+	}
+
+	return -1
+}
+
+func parseXPathPredicate(text string) (xpathPredicate, error) {
+
+	if position, err := strconv.Atoi(text); err == nil {
+		if position < 1 {
+			return xpathPredicate{}, errors.Errorf("the position '%d' must be 1 or more", position)
+		}
+		return xpathPredicate{position: position}, nil
+	}
+
+	predicate := xpathPredicate{}
+
+	name, value, ok := strutil.Cut(text, "=")
+	name = strings.TrimSpace(name)
+	if ok {
+		value = strings.TrimSpace(value)
+		unquoted, quoted := unquotePathText(value)
+		if !quoted {
+			return xpathPredicate{}, errors.Errorf("the value of '[%s]' must be quoted", text)
+		}
+		predicate.value = unquoted
+		predicate.hasValue = true
+	}
+
+	if strings.HasPrefix(name, "@") {
+		predicate.attribute = true
+		name = name[1:]
+	}
+	if name == "" || strings.ContainsAny(name, " ()") {
+		return xpathPredicate{}, errors.Errorf("'[%s]' must be a position, [name='value'] or [@name='value']", text)
+	}
+	predicate.name = name
+
+	return predicate, nil
+}
+
+// find returns the elements selected by the step from the context elements, in document order.
+func (s xpathStep) find(contexts []*xmlElement) []*xmlElement {
+
+	parents := contexts
+	if s.descendant {
+		// // is the children of the descendant-or-self elements.
+		parents = []*xmlElement{}
+		for _, context := range contexts {
+			parents = append(parents, context.descendantsOrSelf()...)
+		}
+	}
+
+	found := []*xmlElement{}
+	seen := map[*xmlElement]bool{}
+
+	for _, parent := range parents {
+
+		children := []*xmlElement{}
+		for _, child := range parent.children {
+			if s.name == "*" || child.name == s.name {
+				children = append(children, child)
+			}
+		}
+
+		for _, predicate := range s.predicates {
+			children = predicate.filter(children)
+		}
+
+		for _, child := range children {
+			if !seen[child] {
+				seen[child] = true
+				found = append(found, child)
+			}
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].start < found[j].start })
+
+	return found
+}
+
+func (e *xmlElement) descendantsOrSelf() []*xmlElement {
+
+	elements := []*xmlElement{e}
+	for _, child := range e.children {
+		elements = append(elements, child.descendantsOrSelf()...)
+	}
+
+	return elements
+}
+
+func (p xpathPredicate) filter(elements []*xmlElement) []*xmlElement {
+
+	if p.position != 0 {
+		if p.position > len(elements) {
+			return []*xmlElement{}
+		}
+		return elements[p.position-1 : p.position]
+	}
+
+	filtered := []*xmlElement{}
+	for _, element := range elements {
+		if p.match(element) {
+			filtered = append(filtered, element)
+		}
+	}
+
+	return filtered
+}
+
+func (p xpathPredicate) match(element *xmlElement) bool {
+
+	if p.attribute {
+		for _, attribute := range element.attributes {
+			if attribute.name == p.name && (!p.hasValue || attribute.value == p.value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, child := range element.children {
+		if child.name == p.name && (!p.hasValue || strings.TrimSpace(child.text) == p.value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,176 @@
+package emv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestXMLLocator(t *testing.T) {
+
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <!-- <version>0.0.0</version> -->
+  <version>1.0.0</version>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>x</artifactId>
+        <version>
+          1.0.0
+        </version>
+      </plugin>
+      <plugin>
+        <artifactId>y</artifactId>
+        <version><![CDATA[1.0.0]]></version>
+      </plugin>
+    </plugins>
+  </build>
+  <description>a &amp; b</description>
+  <empty/>
+  <manifest android:versionName='1.0.0' code="1"/>
+</project>
+`
+
+	tests := []struct {
+		path   string
+		texts  []string
+		places []string
+	}{
+		{"/project/version", []string{"1.0.0"}, []string{"1.0.0"}},
+		{"/project/version/text()", []string{"1.0.0"}, []string{"1.0.0"}},
+		{"//plugin[artifactId='x']/version", []string{"1.0.0"}, []string{"1.0.0"}},
+		{"//plugin[2]/version", []string{"1.0.0"}, []string{"<![CDATA[1.0.0]]>"}},
+		{"//version", []string{"1.0.0", "1.0.0", "1.0.0"}, []string{"1.0.0", "1.0.0", "<![CDATA[1.0.0]]>"}},
+		{"/project/*/plugins/plugin/artifactId", []string{"x", "y"}, []string{"x", "y"}},
+		{"/project/description", []string{"a & b"}, []string{"a &amp; b"}},
+		{"/project/empty", []string{""}, []string{"<empty/>"}},
+		{"//manifest/@android:versionName", []string{"1.0.0"}, []string{"1.0.0"}},
+		{"//manifest[@code='1']/@code", []string{"1"}, []string{"1"}},
+		{"/version", []string{}, []string{}},
+	}
+
+	for _, test := range tests {
+
+		steps, err := parseXPath(test.path)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		places, err := (&xmlLocator{steps: steps}).locate(content)
+		if err != nil {
+			t.Fatalf("failed test\n%s\n%+v", test.path, err)
+		}
+
+		if len(places) != len(test.texts) {
+			t.Fatalf("failed test\n%s: %v", test.path, places)
+		}
+		for i, place := range places {
+			if place.text != test.texts[i] || content[place.start:place.end] != test.places[i] {
+				t.Fatalf("failed test\n%s: %v", test.path, place)
+			}
+		}
+	}
+}
+
+func TestXMLLocator_encode(t *testing.T) {
+
+	content := `<a><b>1</b><c><![CDATA[1]]></c><d/><e x="1" y='1'/></a>`
+
+	tests := []struct {
+		path        string
+		replacement string
+		expect      string
+	}{
+		{"/a/b", "<1&2>", "&lt;1&amp;2&gt;"},
+		{"/a/c", "<1&2>", "<![CDATA[<1&2>]]>"},
+		{"/a/c", "]]>", "]]&gt;"},
+		{"/a/d", "1", "<d>1</d>"},
+		{"/a/d", "", "<d/>"},
+		{"/a/e/@x", `"1'`, `&quot;1'`},
+		{"/a/e/@y", `"1'`, `"1&apos;`},
+	}
+
+	for _, test := range tests {
+
+		steps, err := parseXPath(test.path)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		places, err := (&xmlLocator{steps: steps}).locate(content)
+		if err != nil {
+			t.Fatalf("failed test\n%s\n%+v", test.path, err)
+		}
+
+		encoded, err := places[0].encode(test.replacement)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+		if encoded != test.expect {
+			t.Fatalf("failed test\n%s: %s", test.path, encoded)
+		}
+	}
+}
+
+func TestXMLLocator_error(t *testing.T) {
+
+	{
+		_, err := (&xmlLocator{steps: []xpathStep{{name: "a"}}}).locate("<a>\n  <b>1</b>\n</a>")
+		if err == nil || err.Error() != "the element <a> in line 1 has child elements" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		_, err := (&xmlLocator{steps: []xpathStep{{name: "a"}}}).locate("<a>\n<b>1</a>")
+		if err == nil || err.Error() != "failed to parse as XML: unexpected end element </a> in line 2" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		_, err := (&xmlLocator{steps: []xpathStep{{name: "a"}}}).locate("<a>\n<b>1</b>")
+		if err == nil || err.Error() != "failed to parse as XML: element <a> is not closed" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+}
+
+func TestParseXPath(t *testing.T) {
+
+	result, err := parseXPath(`//plugin[artifactId="a]b"][ 1 ]/@version`)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	expect := []xpathStep{
+		{descendant: true, name: "plugin", predicates: []xpathPredicate{{name: "artifactId", value: "a]b", hasValue: true}, {position: 1}}},
+		{name: "version", attribute: true},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Fatalf("failed test\n%+v", result)
+	}
+}
+
+func TestParseXPath_invalid(t *testing.T) {
+
+	tests := []struct {
+		path   string
+		expect string
+	}{
+		{"project/version", "'project/version' must start with /"},
+		{"/a/@b/c", "an attribute or text() must be the last step in '/a/@b/c'"},
+		{"/a//", "a name is empty in '/a//'"},
+		{"/a[1", "']' is missing in '/a[1'"},
+		{"/a[0]", "'/a[0]' is an invalid XPath: the position '0' must be 1 or more"},
+		{"/a[b=c]", "'/a[b=c]' is an invalid XPath: the value of '[b=c]' must be quoted"},
+		{"/a[last()]", "'/a[last()]' is an invalid XPath: '[last()]' must be a position, [name='value'] or [@name='value']"},
+		{"/a[1]b", "'/' is expected in '/a[1]b'"},
+	}
+
+	for _, test := range tests {
+		_, err := parseXPath(test.path)
+		if err == nil || err.Error() != test.expect {
+			t.Fatalf("failed test\n%s\n%+v", test.path, err)
+		}
+	}
+}
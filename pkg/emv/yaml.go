@@ -0,0 +1,356 @@
+package emv
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlLocator finds the scalar values of the path in the documents of a YAML file.
+// Only the text of the values is replaced, so that the comments, the anchors and the quoting style are kept.
+type yamlLocator struct {
+	path []pathSegment
+}
+
+// yamlValue is a value found by the path.
+type yamlValue struct {
+	node *yaml.Node
+	// flow is true if the value is in a flow collection such as [a, b].
+	flow bool
+}
+
+func (l *yamlLocator) locate(content string) ([]place, error) {
+
+	values := []yamlValue{}
+
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		document := &yaml.Node{}
+		if err := decoder.Decode(document); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to parse as YAML")
+		}
+
+		if len(document.Content) == 0 {
+			continue
+		}
+
+		found := []yamlValue{{node: document.Content[0]}}
+		for _, segment := range l.path {
+			children := []yamlValue{}
+			for _, value := range found {
+				children = append(children, findYAML(value, segment)...)
+			}
+			found = children
+		}
+
+		values = append(values, found...)
+	}
+
+	lineStarts := []int{0}
+	for i, c := range content {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	places := []place{}
+	located := map[int]bool{}
+
+	for _, value := range values {
+
+		node := resolveAlias(value.node)
+		if node.Kind != yaml.ScalarNode {
+			return nil, errors.Errorf("the value in line %d is not a scalar", node.Line)
+		}
+
+		place, err := yamlPlace(content, yamlOffset(content, lineStarts, node), node, value.flow)
+		if err != nil {
+			return nil, err
+		}
+
+		// The anchored value is found once, even if it is referred to by the aliases.
+		if located[place.start] {
+			continue
+		}
+		located[place.start] = true
+
+		places = append(places, place)
+	}
+
+	sort.Slice(places, func(i, j int) bool { return places[i].start < places[j].start })
+
+	return places, nil
+}
+
+// findYAML returns the values of the segment in the mapping or the sequence.
+func findYAML(value yamlValue, segment pathSegment) []yamlValue {
+
+	node := resolveAlias(value.node)
+	flow := value.flow || node.Style&yaml.FlowStyle != 0
+	children := []yamlValue{}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		if segment.kind != keySegment {
+			break
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment.key {
+				children = append(children, yamlValue{node: node.Content[i+1], flow: flow})
+			}
+		}
+	case yaml.SequenceNode:
+		for i, element := range node.Content {
+			if segment.matchIndex(i) || (segment.kind == selectSegment && yamlField(element, segment.key) == segment.value) {
+				children = append(children, yamlValue{node: element, flow: flow})
+			}
+		}
+	}
+
+	return children
+}
+
+// yamlField returns the scalar value of the key in the mapping, or an empty string if it is not found.
+func yamlField(node *yaml.Node, key string) string {
+
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		value := resolveAlias(node.Content[i+1])
+		if node.Content[i].Value == key && value.Kind == yaml.ScalarNode {
+			return value.Value
+		}
+	}
+
+	return ""
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	return node
+}
+
+// yamlOffset returns the offset of the scalar, after its anchor and tag.
+func yamlOffset(content string, lineStarts []int, node *yaml.Node) int {
+
+	// The column is counted in characters.
+	offset := lineStarts[node.Line-1]
+	for i := 1; i < node.Column && offset < len(content); i++ {
+		_, size := utf8.DecodeRuneInString(content[offset:])
+		offset += size
+	}
+
+	for offset < len(content) && (content[offset] == '&' || content[offset] == '!') {
+		for offset < len(content) && !strings.ContainsRune(" \t\r\n", rune(content[offset])) {
+			offset++
+		}
+		for offset < len(content) && (content[offset] == ' ' || content[offset] == '\t') {
+			offset++
+		}
+	}
+
+	return offset
+}
+
+func yamlPlace(content string, offset int, node *yaml.Node, flow bool) (place, error) {
+
+	switch {
+	case node.Style&yaml.DoubleQuotedStyle != 0:
+		end := offset + 1
+		for content[end] != '"' {
+			if content[end] == '\\' {
+				end++
+			}
+			end++
+		}
+
+		return place{
+			start:  offset,
+			end:    end + 1,
+			text:   node.Value,
+			encode: encodeYAMLDoubleQuoted,
+		}, nil
+	case node.Style&yaml.SingleQuotedStyle != 0:
+		end := offset + 1
+		for !(content[end] == '\'' && (end+1 == len(content) || content[end+1] != '\'')) {
+			if content[end] == '\'' {
+				end++
+			}
+			end++
+		}
+
+		return place{
+			start: offset,
+			end:   end + 1,
+			text:  node.Value,
+			encode: func(replacement string) (string, error) {
+				if strings.ContainsAny(replacement, "\r\n") {
+					// The newlines are folded in single-quoted scalars.
+					return encodeYAMLDoubleQuoted(replacement)
+				}
+				return "'" + strings.ReplaceAll(replacement, "'", "''") + "'", nil
+			},
+		}, nil
+	case node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+		return yamlBlockPlace(content, offset), nil
+	case node.Value == "":
+		// An empty value such as "key:" has no text.
+		space := offset > 0 && content[offset-1] != ' ' && content[offset-1] != '\t'
+		return place{
+			start: offset,
+			end:   offset,
+			encode: func(replacement string) (string, error) {
+				if replacement == "" {
+					return "", nil
+				}
+
+				encoded, err := encodeYAMLPlain(replacement, node.ShortTag(), flow)
+				if err != nil || !space {
+					return encoded, err
+				}
+				return " " + encoded, nil
+			},
+		}, nil
+	default:
+		if !strings.HasPrefix(content[offset:], node.Value) {
+			return place{}, errors.Errorf("the multi-line plain scalar in line %d is not supported", node.Line)
+		}
+
+		return place{
+			start: offset,
+			end:   offset + len(node.Value),
+			text:  node.Value,
+			encode: func(replacement string) (string, error) {
+				return encodeYAMLPlain(replacement, node.ShortTag(), flow)
+			},
+		}, nil
+	}
+}
+
+// yamlBlockPlace returns the lines of the literal or folded block scalar at the offset of its indicator (| or >).
+// The indicator and the indentation are kept.
+func yamlBlockPlace(content string, offset int) place {
+
+	lineStart := strings.LastIndex(content[:offset], "\n") + 1
+	parentIndent := yamlIndent(content[lineStart:offset])
+
+	newline := "\n"
+	start := len(content)
+	if index := strings.Index(content[offset:], "\n"); index != -1 {
+		start = offset + index + 1
+		if strings.HasSuffix(content[:start], "\r\n") {
+			newline = "\r\n"
+		}
+	}
+
+	indent := -1
+	end := start
+	for pos := start; pos < len(content); {
+
+		lineEnd := len(content)
+		if index := strings.Index(content[pos:], "\n"); index != -1 {
+			lineEnd = pos + index + 1
+		}
+
+		line := strings.TrimRight(content[pos:lineEnd], "\r\n")
+		pos = lineEnd
+		if strings.TrimSpace(line) == "" {
+			// The blank lines are a part of the block only if they are followed by the content.
+			continue
+		}
+
+		lineIndent := yamlIndent(line)
+		if (indent == -1 && lineIndent <= parentIndent) || (indent != -1 && lineIndent < indent) {
+			break
+		}
+		if indent == -1 {
+			indent = lineIndent
+		}
+		end = lineEnd
+	}
+
+	lines := []string{}
+	if end > start {
+		for _, line := range strings.Split(strings.TrimSuffix(content[start:end], newline), newline) {
+			if len(line) >= indent {
+				line = line[indent:]
+			} else {
+				line = ""
+			}
+			lines = append(lines, line)
+		}
+	}
+	if indent == -1 {
+		indent = parentIndent + 2
+	}
+
+	// The last line of the file may not end with a newline.
+	lastNewline := end == start || strings.HasSuffix(content[start:end], "\n")
+
+	return place{
+		start: start,
+		end:   end,
+		text:  strings.Join(lines, "\n"),
+		encode: func(replacement string) (string, error) {
+			lines := strings.Split(strings.TrimSuffix(replacement, "\n"), "\n")
+			for i, line := range lines {
+				if line != "" {
+					lines[i] = strings.Repeat(" ", indent) + line
+				}
+			}
+
+			encoded := strings.Join(lines, newline)
+			if lastNewline {
+				encoded += newline
+			}
+			return encoded, nil
+		},
+	}
+}
+
+func yamlIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func encodeYAMLDoubleQuoted(replacement string) (string, error) {
+	return `"` + escapeYAML(replacement) + `"`, nil
+}
+
+// encodeYAMLPlain keeps the replacement plain if it is read as the same text,
+// and as a string or the same type as the original value. (e.g. 1.10 for a string is not read as a float)
+// Otherwise, it is double-quoted.
+func encodeYAMLPlain(replacement string, tag string, flow bool) (string, error) {
+
+	if replacement == "" || strings.ContainsAny(replacement, "\r\n") || (flow && strings.ContainsAny(replacement, ",[]{}")) {
+		return encodeYAMLDoubleQuoted(replacement)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(replacement), &document); err != nil || len(document.Content) != 1 {
+		return encodeYAMLDoubleQuoted(replacement)
+	}
+
+	node := document.Content[0]
+	if node.Kind != yaml.ScalarNode || node.Style != 0 || node.Value != replacement {
+		return encodeYAMLDoubleQuoted(replacement)
+	}
+
+	if node.ShortTag() != "!!str" && node.ShortTag() != tag {
+		return encodeYAMLDoubleQuoted(replacement)
+	}
+
+	return replacement, nil
+}
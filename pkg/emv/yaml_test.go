@@ -0,0 +1,151 @@
+package emv
+
+import (
+	"testing"
+)
+
+func TestYAMLLocator(t *testing.T) {
+
+	content := `# values
+image:
+  repository: app # the image
+  tag: "1.0.0"
+base: &base 1.0.0
+copy: *base
+name: 'it''s'
+empty:
+ports: [80, 'a', 443]
+spec:
+  containers:
+    - name: sidecar
+      image: sidecar:1.0.0
+    - name: app
+      image: app:1.0.0
+script: |
+  echo 1.0.0
+
+  echo done
+multi: é
+---
+image:
+  tag: 2.0.0
+`
+
+	tests := []struct {
+		path   string
+		texts  []string
+		places []string
+	}{
+		{"image.tag", []string{"1.0.0", "2.0.0"}, []string{`"1.0.0"`, "2.0.0"}},
+		{"image.repository", []string{"app"}, []string{"app"}},
+		{"/base", []string{"1.0.0"}, []string{"1.0.0"}},
+		{"copy", []string{"1.0.0"}, []string{"1.0.0"}},
+		{"name", []string{"it's"}, []string{"'it''s'"}},
+		{"empty", []string{""}, []string{""}},
+		{"ports[1]", []string{"a"}, []string{"'a'"}},
+		{"spec.containers[name=app].image", []string{"app:1.0.0"}, []string{"app:1.0.0"}},
+		{"spec.containers[0].image", []string{"sidecar:1.0.0"}, []string{"sidecar:1.0.0"}},
+		{"script", []string{"echo 1.0.0\n\necho done"}, []string{"  echo 1.0.0\n\n  echo done\n"}},
+		{"multi", []string{"é"}, []string{"é"}},
+		{"none", []string{}, []string{}},
+	}
+
+	for _, test := range tests {
+
+		path, err := parsePath(test.path)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		places, err := (&yamlLocator{path: path}).locate(content)
+		if err != nil {
+			t.Fatalf("failed test\n%s\n%+v", test.path, err)
+		}
+
+		if len(places) != len(test.texts) {
+			t.Fatalf("failed test\n%s: %v", test.path, places)
+		}
+		for i, place := range places {
+			if place.text != test.texts[i] || content[place.start:place.end] != test.places[i] {
+				t.Fatalf("failed test\n%s: %v", test.path, place)
+			}
+		}
+	}
+}
+
+func TestYAMLLocator_encode(t *testing.T) {
+
+	content := "plain: a\nnumber: 1.0\nflag: false\ndouble: \"a\"\nsingle: 'a'\nempty:\nflow: [a, b]\nblock: |\n    a\n    b\nlast: >\n  a"
+
+	tests := []struct {
+		path        string
+		replacement string
+		expect      string
+	}{
+		{"plain", "2.0.0", "2.0.0"},
+		{"plain", "a: b", `"a: b"`},
+		{"plain", "a #b", `"a #b"`},
+		{"plain", "", `""`},
+		{"plain", "a\nb", `"a\nb"`},
+		{"plain", "1.10", `"1.10"`},
+		{"plain", "true", `"true"`},
+		{"plain", "null", `"null"`},
+		{"number", "1.10", "1.10"},
+		{"number", "latest", "latest"},
+		{"flag", "true", "true"},
+		{"double", `a"b`, `"a\"b"`},
+		{"single", "it's", "'it''s'"},
+		{"single", "a\nb", `"a\nb"`},
+		{"empty", "1.0.0", " 1.0.0"},
+		{"empty", "", ""},
+		{"empty", "true", ` "true"`},
+		{"empty", "null", " null"},
+		{"flow[0]", "x y", "x y"},
+		{"flow[0]", "x,y", `"x,y"`},
+		{"block", "x\n\ny\n", "    x\n\n    y\n"},
+		{"last", "x\ny", "  x\n  y"},
+	}
+
+	for _, test := range tests {
+
+		path, err := parsePath(test.path)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+
+		places, err := (&yamlLocator{path: path}).locate(content)
+		if err != nil {
+			t.Fatalf("failed test\n%s\n%+v", test.path, err)
+		}
+
+		encoded, err := places[0].encode(test.replacement)
+		if err != nil {
+			t.Fatalf("failed test\n%+v", err)
+		}
+		if encoded != test.expect {
+			t.Fatalf("failed test\n%s: %s", test.path, encoded)
+		}
+	}
+}
+
+func TestYAMLLocator_error(t *testing.T) {
+
+	{
+		_, err := (&yamlLocator{path: []pathSegment{{kind: keySegment, key: "a"}}}).locate("a:\n  b: 1\n")
+		if err == nil || err.Error() != "the value in line 2 is not a scalar" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		_, err := (&yamlLocator{path: []pathSegment{{kind: keySegment, key: "a"}}}).locate("a: x\n  y\n")
+		if err == nil || err.Error() != "the multi-line plain scalar in line 1 is not supported" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+	{
+		_, err := (&yamlLocator{}).locate("a: [\n")
+		if err == nil || err.Error() != "failed to parse as YAML: yaml: line 1: did not find expected node content" {
+			t.Fatalf("failed test\n%+v", err)
+		}
+	}
+}
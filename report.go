@@ -0,0 +1,96 @@
+package main
+
+import "github.com/onozaty/emv/pkg/emv"
+
+type RunReport struct {
+	// Mode is "write", "dry-run" or "check".
+	Mode     string            `json:"mode"`
+	Bumped   *emv.Bumped       `json:"bumped,omitempty"`
+	Values   map[string]string `json:"values"`
+	Targets  []TargetReport    `json:"targets"`
+	Warnings []string          `json:"warnings"`
+}
+
+type TargetReport struct {
+	Rules []RuleReport `json:"rules"`
+	Files []FileReport `json:"files"`
+}
+
+type RuleReport struct {
+	Kind        string `json:"kind,omitempty"`
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+type FileReport struct {
+	File string `json:"file"`
+	// Status is "updated" or "unchanged". In dry-run and check mode, "updated" means the file would be updated.
+	Status string `json:"status"`
+	// Matches are the number of matches for each rule.
+	Matches      []int  `json:"matches"`
+	ChangedLines []int  `json:"changedLines"`
+	Diff         string `json:"diff,omitempty"`
+}
+
+func runReport(options Options, bumped *emv.Bumped, values map[string]string, targetPlans []emv.TargetPlan, warnings []string) RunReport {
+
+	mode := "write"
+	switch {
+	case options.Check:
+		mode = "check"
+	case options.DryRun:
+		mode = "dry-run"
+	}
+
+	targetReports := []TargetReport{}
+	for _, targetPlan := range targetPlans {
+
+		ruleReports := []RuleReport{}
+		for _, replaceRule := range targetPlan.ReplaceRules {
+			ruleReports = append(ruleReports, RuleReport{
+				Kind:        replaceRule.Kind,
+				Pattern:     replaceRule.Location,
+				Replacement: replaceRule.Replacement,
+			})
+		}
+
+		fileReports := []FileReport{}
+		for _, file := range targetPlan.Files {
+
+			fileReport := FileReport{
+				File:         file.Name,
+				Status:       "unchanged",
+				Matches:      file.MatchCounts,
+				ChangedLines: []int{},
+			}
+
+			if file.Changed() {
+				fileReport.Status = "updated"
+				fileReport.ChangedLines = file.ChangedLines()
+
+				if options.DryRun {
+					fileReport.Diff = file.Diff()
+				}
+			}
+
+			fileReports = append(fileReports, fileReport)
+		}
+
+		targetReports = append(targetReports, TargetReport{
+			Rules: ruleReports,
+			Files: fileReports,
+		})
+	}
+
+	if warnings == nil {
+		warnings = []string{}
+	}
+
+	return RunReport{
+		Mode:     mode,
+		Bumped:   bumped,
+		Values:   values,
+		Targets:  targetReports,
+		Warnings: warnings,
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/onozaty/emv/pkg/emv"
+	"github.com/pkg/errors"
+)
+
+type ValidationReport struct {
+	Errors []emv.ValidationError `json:"errors"`
+}
+
+// runValidate checks the config file and reports all errors found.
+func runValidate(configPath string, options Options, w io.Writer) error {
+
+	if err := validateOutput(options.Output); err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "failed to load the config file")
+	}
+
+	validationErrors, err := emv.ValidateConfig(configPath, content)
+	if err != nil {
+		return errors.Wrap(err, "failed to load the config file")
+	}
+
+	if options.Output == "json" {
+		if err := writeJSON(w, ValidationReport{Errors: validationErrors}); err != nil {
+			return err
+		}
+	} else if len(validationErrors) == 0 {
+		fmt.Fprintf(w, "%s is valid.\n", configPath)
+	} else {
+		fmt.Fprintf(w, "%s has errors:\n", configPath)
+		for _, validationError := range validationErrors {
+			fmt.Fprintf(w, "  %s\n", validationError)
+		}
+	}
+
+	if len(validationErrors) != 0 {
+		return errors.Errorf("%d error(s) in the config file", len(validationErrors))
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestRunValidate(t *testing.T) {
+
+	w := &bytes.Buffer{}
+	err := runValidate("examples/regex/emv.json", Options{}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if w.String() != "examples/regex/emv.json is valid.\n" {
+		t.Fatal("failed test\n", w.String())
+	}
+}
+
+func TestRunValidate_json(t *testing.T) {
+
+	w := &bytes.Buffer{}
+	err := runValidate("examples/regex/emv.json", Options{Output: "json"}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if w.String() != "{\n  \"errors\": []\n}\n" {
+		t.Fatal("failed test\n", w.String())
+	}
+}
+
+func TestRunValidate_error(t *testing.T) {
+
+	file := createTempFile(t, `{"values": [{"name": "version"}], "targets": [{"files": ["a.txt"], "embedded": []}]}`)
+	defer os.Remove(file)
+
+	w := &bytes.Buffer{}
+	err := runValidate(file, Options{}, w)
+	if err == nil || err.Error() != "1 error(s) in the config file" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	if w.String() != file+" has errors:\n  $.targets[0].embedded (1:68): unknown field 'embedded'\n" {
+		t.Fatal("failed test\n", w.String())
+	}
+}
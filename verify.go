@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/onozaty/emv/pkg/emv"
+	"github.com/pkg/errors"
+)
+
+type ConsistencyReport struct {
+	Values []emv.Consistency `json:"values"`
+}
+
+// runVerifyConsistent checks that each value embedded in the target files is the same everywhere.
+func runVerifyConsistent(configPath string, targetDirPath string, options Options, w io.Writer) error {
+
+	if err := validateOutput(options.Output); err != nil {
+		return err
+	}
+
+	config, err := emv.LoadConfig(configPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to load the config file")
+	}
+
+	fileExtractions, err := emv.NewEngine(config, targetDirPath).Extract()
+	if err != nil {
+		return err
+	}
+
+	consistencies := emv.Consistencies(fileExtractions)
+
+	inconsistentCount := 0
+	for _, consistency := range consistencies {
+		if !consistency.Consistent {
+			inconsistentCount++
+		}
+	}
+
+	if options.Output == "json" {
+		if err := writeJSON(w, ConsistencyReport{Values: consistencies}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(w, "Values: ([OK] Consistent, [NG] Inconsistent)\n")
+		for _, consistency := range consistencies {
+			if consistency.Consistent {
+				fmt.Fprintf(w, "  [OK] %s: %s\n", consistency.Name, consistency.Occurrences[0].Value)
+				continue
+			}
+
+			fmt.Fprintf(w, "  [NG] %s\n", consistency.Name)
+			for _, occurrence := range consistency.Occurrences {
+				fmt.Fprintf(w, "    %s: %s L%d\n", occurrence.Value, occurrence.File, occurrence.Line)
+			}
+		}
+	}
+
+	if inconsistentCount != 0 {
+		return errors.Errorf("%d value(s) are inconsistent", inconsistentCount)
+	}
+
+	return nil
+}
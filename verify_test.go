@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunVerifyConsistent(t *testing.T) {
+
+	targetFile1 := createTempFile(t, "version=v1.0.0\ndate=2021-12-24")
+	defer os.Remove(targetFile1)
+	targetFile2 := createTempFile(t, "name=x\nversion=v1.0.0")
+	defer os.Remove(targetFile2)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s",
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					},
+					{
+						"pattern" : "date=[0-9\\-]+",
+						"replacement" : "date={{.date}}"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`),
+		strings.ReplaceAll(targetFile2, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := runVerifyConsistent(configFile, "", Options{}, w)
+	if err != nil {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	output := w.String()
+	expect := `Values: ([OK] Consistent, [NG] Inconsistent)
+  [OK] date: 2021-12-24
+  [OK] version: 1.0.0
+`
+	if output != expect {
+		t.Fatal("failed test\n", output)
+	}
+}
+
+func TestRunVerifyConsistent_inconsistent(t *testing.T) {
+
+	targetFile1 := createTempFile(t, "version=v1.0.0")
+	defer os.Remove(targetFile1)
+	targetFile2 := createTempFile(t, "name=x\nversion=v0.9.0")
+	defer os.Remove(targetFile2)
+	targetFile3 := createTempFile(t, "<version>1.0.0</version>")
+	defer os.Remove(targetFile3)
+
+	config := fmt.Sprintf(`
+	{
+		"values" : [
+			{ 
+				"name" : "version"
+			}
+		],
+		"targets" : [
+			{
+				"files" : [
+					"%s",
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "version=v[0-9]+\\.[0-9]+\\.[0-9]+",
+						"replacement" : "version=v{{.version}}"
+					}
+				]
+			},
+			{
+				"files" : [
+					"%s"
+				],
+				"embeddeds" : [
+					{
+						"pattern" : "<version>.+</version>",
+						"replacement" : "<version>{{.version}}</version>"
+					}
+				]
+			}
+		]
+	}`,
+		strings.ReplaceAll(targetFile1, `\`, `\\`),
+		strings.ReplaceAll(targetFile2, `\`, `\\`),
+		strings.ReplaceAll(targetFile3, `\`, `\\`))
+
+	configFile := createTempFile(t, config)
+	defer os.Remove(configFile)
+
+	w := &bytes.Buffer{}
+	err := runVerifyConsistent(configFile, "", Options{}, w)
+	if err == nil || err.Error() != "1 value(s) are inconsistent" {
+		t.Fatalf("failed test\n%+v", err)
+	}
+
+	output := w.String()
+	expect := fmt.Sprintf(`Values: ([OK] Consistent, [NG] Inconsistent)
+  [NG] version
+    1.0.0: %s L1
+    1.0.0: %s L1
+    0.9.0: %s L2
+`, targetFile1, targetFile3, targetFile2)
+
+	if output != expect {
+		t.Fatal("failed test\n", output)
+	}
+}